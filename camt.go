@@ -0,0 +1,425 @@
+package clbnk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISO 20022 camt.053 (BankToCustomerStatement) import.
+// Only the elements needed for BankImport are described,
+// namespaces are ignored so any camt.053.001.xx version is accepted.
+
+const (
+	CAMT_CREDIT = "CRDT"
+	CAMT_DEBIT  = "DBIT"
+
+	CAMT_BAL_OPENING      = "OPBD"
+	CAMT_BAL_PREV_CLOSING = "PRCD"
+	CAMT_BAL_CLOSING      = "CLBD"
+)
+
+type camtDocument struct {
+	Stmt struct {
+		GrpHdr struct {
+			MsgId   string `xml:"MsgId"`
+			CreDtTm string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Stmts []camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	Id      string `xml:"Id"`
+	CreDtTm string `xml:"CreDtTm"`
+	FrToDt  struct {
+		FrDtTm string `xml:"FrDtTm"`
+		ToDtTm string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Acct      camtAccount   `xml:"Acct"`
+	Bal       []camtBalance `xml:"Bal"`
+	TxsSummry struct {
+		TtlCdtNtries struct {
+			Sum string `xml:"Sum"`
+		} `xml:"TtlCdtNtries"`
+		TtlDbtNtries struct {
+			Sum string `xml:"Sum"`
+		} `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Ntry []camtEntry `xml:"Ntry"`
+}
+
+type camtAccount struct {
+	Id struct {
+		IBAN string `xml:"IBAN"`
+		Othr struct {
+			Id string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+	Ccy  string    `xml:"Ccy"`
+	Svcr camtAgent `xml:"Svcr"`
+}
+
+func (a *camtAccount) number() string {
+	if a.Id.Othr.Id != "" {
+		return a.Id.Othr.Id
+	}
+	return a.Id.IBAN
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+type camtBalance struct {
+	Tp struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+type camtAgent struct {
+	FinInstnId struct {
+		BIC         string `xml:"BIC"`
+		BICFI       string `xml:"BICFI"`
+		ClrSysMmbId struct {
+			MmbId string `xml:"MmbId"`
+		} `xml:"ClrSysMmbId"`
+		Nm      string `xml:"Nm"`
+		PstlAdr struct {
+			TwnNm string `xml:"TwnNm"`
+		} `xml:"PstlAdr"`
+	} `xml:"FinInstnId"`
+}
+
+type camtPartyId struct {
+	OrgId struct {
+		Othr []camtOtherId `xml:"Othr"`
+	} `xml:"OrgId"`
+	PrvtId struct {
+		Othr []camtOtherId `xml:"Othr"`
+	} `xml:"PrvtId"`
+}
+
+type camtOtherId struct {
+	Id      string `xml:"Id"`
+	SchmeNm struct {
+		Cd    string `xml:"Cd"`
+		Prtry string `xml:"Prtry"`
+	} `xml:"SchmeNm"`
+}
+
+type camtParty struct {
+	Nm  string      `xml:"Nm"`
+	Id  camtPartyId `xml:"Id"`
+	Pty struct {
+		Nm string      `xml:"Nm"`
+		Id camtPartyId `xml:"Id"`
+	} `xml:"Pty"` // camt.053.001.08 and later
+}
+
+// name returns party name.
+func (p *camtParty) name() string {
+	if p.Nm != "" {
+		return p.Nm
+	}
+	return p.Pty.Nm
+}
+
+// inn returns party INN: other identification with TXID code or INN proprietary scheme.
+func (p *camtParty) inn() string {
+	for _, id := range []camtPartyId{p.Id, p.Pty.Id} {
+		for _, othrs := range [][]camtOtherId{id.OrgId.Othr, id.PrvtId.Othr} {
+			for _, o := range othrs {
+				if o.SchmeNm.Cd == "TXID" || strings.EqualFold(o.SchmeNm.Prtry, "INN") {
+					return o.Id
+				}
+			}
+		}
+	}
+	return ""
+}
+
+type camtTransaction struct {
+	Refs struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+		InstrId     string `xml:"InstrId"`
+		EndToEndId  string `xml:"EndToEndId"`
+	} `xml:"Refs"`
+	Amt     camtAmount `xml:"Amt"`
+	AmtDtls struct {
+		TxAmt struct {
+			Amt camtAmount `xml:"Amt"`
+		} `xml:"TxAmt"`
+	} `xml:"AmtDtls"`
+	RltdPties struct {
+		Dbtr     camtParty   `xml:"Dbtr"`
+		DbtrAcct camtAccount `xml:"DbtrAcct"`
+		Cdtr     camtParty   `xml:"Cdtr"`
+		CdtrAcct camtAccount `xml:"CdtrAcct"`
+	} `xml:"RltdPties"`
+	RltdAgts struct {
+		DbtrAgt camtAgent `xml:"DbtrAgt"`
+		CdtrAgt camtAgent `xml:"CdtrAgt"`
+	} `xml:"RltdAgts"`
+	RmtInf struct {
+		Ustrd []string `xml:"Ustrd"`
+	} `xml:"RmtInf"`
+}
+
+type camtEntry struct {
+	Amt         camtAmount `xml:"Amt"`
+	CdtDbtInd   string     `xml:"CdtDbtInd"`
+	BookgDt     camtDate   `xml:"BookgDt"`
+	ValDt       camtDate   `xml:"ValDt"`
+	AcctSvcrRef string     `xml:"AcctSvcrRef"`
+	NtryDtls    []struct {
+		TxDtls []camtTransaction `xml:"TxDtls"`
+	} `xml:"NtryDtls"`
+}
+
+// parseCamtDate parses ISO date or date time values.
+func parseCamtDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if len(s) == len("2006-01-02") {
+		return time.Parse("2006-01-02", s)
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("camt: invalid date %s", s)
+}
+
+// date returns the date part of the value.
+func (d camtDate) date() (time.Time, error) {
+	v := d.Dt
+	if v == "" {
+		v = d.DtTm
+	}
+	t, err := parseCamtDate(v)
+	if err != nil || t.IsZero() {
+		return t, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func parseCamtAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("camt: invalid amount %s", s)
+	}
+	return v, nil
+}
+
+func (a *camtAgent) bankInfo() BankInfo {
+	bik := a.FinInstnId.ClrSysMmbId.MmbId
+	if bik == "" {
+		bik = a.FinInstnId.BIC
+	}
+	if bik == "" {
+		bik = a.FinInstnId.BICFI
+	}
+	return BankInfo{Name: a.FinInstnId.Nm, Place: a.FinInstnId.PstlAdr.TwnNm, Bik: bik}
+}
+
+// UnmarshalCamt053 imports ISO 20022 camt.053 statement.
+// Every Stmt element is added to AccSection, every transaction (TxDtls)
+// or entry without details is added to Documents as PPDocument.
+func (e *BankImport) UnmarshalCamt053(data []byte) error {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("camt: %v", err)
+	}
+	if len(doc.Stmt.Stmts) == 0 {
+		return fmt.Errorf("camt: %s", ER_INVALID_FILE)
+	}
+	if t, err := parseCamtDate(doc.Stmt.GrpHdr.CreDtTm); err == nil && !t.IsZero() {
+		e.CreateDate = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		e.CreateTime = t.Format("15:04:05")
+	}
+	for i := range doc.Stmt.Stmts {
+		if err := e.addCamtStatement(&doc.Stmt.Stmts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *BankImport) addCamtStatement(st *camtStatement) error {
+	acc := Account{Account: st.Acct.number(), Currency: st.Acct.Ccy}
+	var err error
+	if acc.DateFrom, err = parseCamtDate(st.FrToDt.FrDtTm); err != nil {
+		return err
+	}
+	if acc.DateTo, err = parseCamtDate(st.FrToDt.ToDtTm); err != nil {
+		return err
+	}
+	for _, bal := range st.Bal {
+		sum, err := parseCamtAmount(bal.Amt.Value)
+		if err != nil {
+			return err
+		}
+		if bal.CdtDbtInd == CAMT_DEBIT {
+			sum = -sum
+		}
+		if acc.Currency == "" {
+			acc.Currency = bal.Amt.Ccy
+		}
+		bal_date, err := bal.Dt.date()
+		if err != nil {
+			return err
+		}
+		switch bal.Tp.CdOrPrtry.Cd {
+		case CAMT_BAL_OPENING, CAMT_BAL_PREV_CLOSING:
+			acc.BalanceStart = sum
+			if acc.DateFrom.IsZero() {
+				acc.DateFrom = bal_date
+			}
+		case CAMT_BAL_CLOSING:
+			acc.BalanceEnd = sum
+			if acc.DateTo.IsZero() {
+				acc.DateTo = bal_date
+			}
+		}
+	}
+	acc.DateFrom = truncateDate(acc.DateFrom)
+	acc.DateTo = truncateDate(acc.DateTo)
+
+	var credit, debit float64
+	for i := range st.Ntry {
+		docs, err := camtEntryDocuments(&st.Ntry[i], &st.Acct)
+		if err != nil {
+			return err
+		}
+		for _, d := range docs {
+			if st.Ntry[i].CdtDbtInd == CAMT_CREDIT {
+				credit += d.Sum
+			} else {
+				debit += d.Sum
+			}
+			e.Documents = append(e.Documents, d)
+		}
+	}
+	//totals from summary if given
+	if st.TxsSummry.TtlCdtNtries.Sum != "" || st.TxsSummry.TtlDbtNtries.Sum != "" {
+		if credit, err = parseCamtAmount(st.TxsSummry.TtlCdtNtries.Sum); err != nil {
+			return err
+		}
+		if debit, err = parseCamtAmount(st.TxsSummry.TtlDbtNtries.Sum); err != nil {
+			return err
+		}
+	}
+	acc.Debet = credit
+	acc.Kredit = debit
+
+	e.AccSection = append(e.AccSection, acc)
+	if e.Account == "" {
+		e.Account = acc.Account
+	}
+	if e.DateFrom.IsZero() || (!acc.DateFrom.IsZero() && acc.DateFrom.Before(e.DateFrom)) {
+		e.DateFrom = acc.DateFrom
+	}
+	if e.DateTo.IsZero() || acc.DateTo.After(e.DateTo) {
+		e.DateTo = acc.DateTo
+	}
+	return nil
+}
+
+func truncateDate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// camtEntryDocuments creates documents of the entry.
+func camtEntryDocuments(ntry *camtEntry, acct *camtAccount) ([]*PPDocument, error) {
+	book_date, err := ntry.BookgDt.date()
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]camtTransaction, 0)
+	for _, dtls := range ntry.NtryDtls {
+		txs = append(txs, dtls.TxDtls...)
+	}
+	if len(txs) == 0 {
+		tx := camtTransaction{Amt: ntry.Amt}
+		tx.Refs.AcctSvcrRef = ntry.AcctSvcrRef
+		txs = append(txs, tx)
+	}
+	docs := make([]*PPDocument, 0, len(txs))
+	for _, tx := range txs {
+		amt, ccy := tx.Amt.Value, tx.Amt.Ccy
+		if amt == "" {
+			amt, ccy = tx.AmtDtls.TxAmt.Amt.Value, tx.AmtDtls.TxAmt.Amt.Ccy
+		}
+		if amt == "" && len(txs) == 1 {
+			amt, ccy = ntry.Amt.Value, ntry.Amt.Ccy
+		}
+		if ccy == "" {
+			ccy = ntry.Amt.Ccy
+		}
+		sum, err := parseCamtAmount(amt)
+		if err != nil {
+			return nil, err
+		}
+		doc := &PPDocument{Date: book_date,
+			Sum:        sum,
+			Currency:   ccy,
+			PayComment: strings.Join(tx.RmtInf.Ustrd, " "),
+			Payer: Party{Name: tx.RltdPties.Dbtr.name(),
+				Inn:     tx.RltdPties.Dbtr.inn(),
+				Account: tx.RltdPties.DbtrAcct.number(),
+				Bank:    tx.RltdAgts.DbtrAgt.bankInfo(),
+			},
+			Receiver: Party{Name: tx.RltdPties.Cdtr.name(),
+				Inn:     tx.RltdPties.Cdtr.inn(),
+				Account: tx.RltdPties.CdtrAcct.number(),
+				Bank:    tx.RltdAgts.CdtrAgt.bankInfo(),
+			},
+		}
+		for _, ref := range []string{tx.Refs.InstrId, tx.Refs.EndToEndId, tx.Refs.AcctSvcrRef} {
+			if n, err := strconv.Atoi(ref); err == nil {
+				doc.Num = n
+				break
+			}
+		}
+		//statement account side
+		if ntry.CdtDbtInd == CAMT_CREDIT {
+			doc.DebetDate = book_date
+			if doc.Receiver.Account == "" {
+				doc.Receiver.Account = acct.number()
+				doc.Receiver.Bank = acct.Svcr.bankInfo()
+			}
+		} else {
+			doc.KreditDate = book_date
+			if doc.Payer.Account == "" {
+				doc.Payer.Account = acct.number()
+				doc.Payer.Bank = acct.Svcr.bankInfo()
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
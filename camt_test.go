@@ -0,0 +1,56 @@
+package clbnk
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+const TEST_CAMT_ACC = "40702810000000074935"
+
+func TestImportCamt053(t *testing.T) {
+	f_cont, err := os.ReadFile("camt053.xml")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.UnmarshalCamt053(f_cont); err != nil {
+		t.Fatalf("UnmarshalCamt053 failed: %v", err)
+	}
+	if imp.Account != TEST_CAMT_ACC {
+		t.Fatalf("account, expected %s, got %s", TEST_CAMT_ACC, imp.Account)
+	}
+	if len(imp.AccSection) != 1 {
+		t.Fatalf("account section count, expected 1, got %d", len(imp.AccSection))
+	}
+	acc := imp.AccSection[0]
+	if acc.BalanceStart != 252842.49 || acc.BalanceEnd != 122842.49 || acc.Debet != 20000 || acc.Kredit != 150000 {
+		t.Fatalf("account section values, got %+v", acc)
+	}
+	if acc.Currency != CURRENCY_RUB || imp.Documents[0].GetCurrency() != CURRENCY_RUB {
+		t.Fatalf("currency, expected %s, got %s", CURRENCY_RUB, acc.Currency)
+	}
+	if !acc.DateFrom.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("account section date from, got %v", acc.DateFrom)
+	}
+	if len(imp.Documents) != 2 {
+		t.Fatalf("document count, expected 2, got %d", len(imp.Documents))
+	}
+	in := imp.Incoming("")
+	if len(in) != 1 {
+		t.Fatalf("incoming document count, expected 1, got %d", len(in))
+	}
+	doc := in[0].(*PPDocument)
+	if doc.Num != 125 || doc.Sum != 20000 || doc.Payer.Inn != "7123456789012" || doc.Payer.Bank.Bik != "047102651" {
+		t.Fatalf("incoming document, got %+v", doc)
+	}
+	if doc.PayComment != "Оплата по счету №125 от 05.01.2024 НДС не облагается" {
+		t.Fatalf("incoming document purpose, got %s", doc.PayComment)
+	}
+	out := imp.Outgoing("")
+	if len(out) != 1 || out[0].GetPayer().Inn != "7123456777" || out[0].GetNum() != 2 {
+		t.Fatalf("outgoing document, got %+v", out)
+	}
+
+	testJSONRoundTrip(t, imp)
+}
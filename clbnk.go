@@ -3,7 +3,9 @@ package clbnk
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding/charmap"
 )
@@ -27,9 +29,25 @@ const (
 
 type EncodingType int
 
+func EncodingTypeValues() []string {
+	return []string{ENCODING_WIN, ENCODING_DOS}
+}
+
+// String returns the exchange format value of the encoding.
+func (e EncodingType) String() string {
+	v := EncodingTypeValues()
+	if e < 0 || int(e) >= len(v) {
+		return fmt.Sprintf("EncodingType(%d)", int(e))
+	}
+	return v[int(e)]
+}
+
 func (e EncodingType) Marshal() ([]byte, error) {
-	v := [][]byte{[]byte(ENCODING_WIN), []byte(ENCODING_DOS)}
-	return v[int(e)], nil
+	v := EncodingTypeValues()
+	if e < 0 || int(e) >= len(v) {
+		return []byte{}, fmt.Errorf("%s: %d", ER_NO_ENC, int(e))
+	}
+	return []byte(v[int(e)]), nil
 }
 
 func (e *EncodingType) Unmarshal(data string) error {
@@ -40,17 +58,43 @@ func (e *EncodingType) Unmarshal(data string) error {
 		*e = ENCODING_TYPE_DOS
 
 	} else {
-		return fmt.Errorf("encoding not defined")
+		return fmt.Errorf("%s: %s", ER_NO_ENC, data)
 	}
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler.
+// ENCODING_TYPE_NOT_DEFINED of imports from other formats is an empty string.
+func (e EncodingType) MarshalText() ([]byte, error) {
+	if e == ENCODING_TYPE_NOT_DEFINED {
+		return []byte{}, nil
+	}
+	return e.Marshal()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *EncodingType) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*e = ENCODING_TYPE_NOT_DEFINED
+		return nil
+	}
+	return e.Unmarshal(string(data))
+}
+
+func (e EncodingType) charmap() (*charmap.Charmap, error) {
+	switch e {
+	case ENCODING_TYPE_WIN:
+		return charmap.Windows1251, nil
+	case ENCODING_TYPE_DOS:
+		return charmap.CodePage866, nil
+	}
+	return nil, fmt.Errorf("%s: %d", ER_NO_ENC, int(e))
+}
+
 func (e EncodingType) decode(s []byte) ([]byte, error) {
-	var char_map *charmap.Charmap
-	if e == ENCODING_TYPE_WIN {
-		char_map = charmap.Windows1251
-	} else {
-		char_map = charmap.CodePage866
+	char_map, err := e.charmap()
+	if err != nil {
+		return []byte{}, err
 	}
 	dec := char_map.NewDecoder()
 	out, err := dec.Bytes(s)
@@ -61,16 +105,88 @@ func (e EncodingType) decode(s []byte) ([]byte, error) {
 }
 
 func (e EncodingType) encode(s []byte) ([]byte, error) {
-	var char_map *charmap.Charmap
-	if e == ENCODING_TYPE_WIN {
-		char_map = charmap.Windows1251
-	} else {
-		char_map = charmap.CodePage866
+	char_map, err := e.charmap()
+	if err != nil {
+		return []byte{}, err
 	}
 	enc := char_map.NewEncoder()
 	return enc.Bytes(s)
 }
 
+// DetectEncoding returns the encoding type of exchange file data
+// by its Кодировка value.
+func DetectEncoding(data []byte) (EncodingType, error) {
+	lines := strings.SplitN(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n", 4)
+	if len(lines) < 3 {
+		return ENCODING_TYPE_NOT_DEFINED, fmt.Errorf(ER_INVALID_FILE)
+	}
+	if lines[0] != HEADER {
+		return ENCODING_TYPE_NOT_DEFINED, fmt.Errorf("file header not found %v!=%v", []byte(lines[0]), []byte(HEADER))
+	}
+	enc := strings.Split(lines[2], "=")
+	if len(enc) < 2 {
+		return ENCODING_TYPE_NOT_DEFINED, fmt.Errorf(ER_NO_ENC)
+	}
+	var e EncodingType
+	if err := e.Unmarshal(enc[1]); err != nil {
+		return ENCODING_TYPE_NOT_DEFINED, err
+	}
+	return e, nil
+}
+
+// DecodeFile returns exchange file data in UTF-8 and the encoding type
+// given in the file. Data with non ASCII characters that is already valid UTF-8
+// is returned as is.
+func DecodeFile(data []byte) ([]byte, EncodingType, error) {
+	enc, err := DetectEncoding(data)
+	if err != nil {
+		return nil, ENCODING_TYPE_NOT_DEFINED, err
+	}
+	if isUTF8Text(data) {
+		return data, enc, nil
+	}
+	dec, err := enc.decode(data)
+	if err != nil {
+		return nil, ENCODING_TYPE_NOT_DEFINED, err
+	}
+	return dec, enc, nil
+}
+
+// ConvertEncoding converts exchange file data to the encoding type,
+// Кодировка value is changed accordingly. Source data may be in any
+// encoding accepted by DecodeFile.
+func ConvertEncoding(data []byte, to EncodingType) ([]byte, error) {
+	to_val, err := to.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	dec, _, err := DecodeFile(data)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitN(string(dec), "\n", 4)
+	key, _, _ := strings.Cut(lines[2], "=")
+	line_end := ""
+	if strings.HasSuffix(lines[2], "\r") {
+		line_end = "\r"
+	}
+	lines[2] = key + "=" + string(to_val) + line_end
+	return to.encode([]byte(strings.Join(lines, "\n")))
+}
+
+// isUTF8Text returns true if data is valid UTF-8 with non ASCII characters.
+func isUTF8Text(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
 // DocumentType
 type DocumentType int
 
@@ -80,8 +196,41 @@ func DocumentTypeValues() []string {
 	}
 }
 
+// String returns the exchange format value of the document type.
+func (d DocumentType) String() string {
+	v := DocumentTypeValues()
+	if d < 0 || int(d) >= len(v) {
+		return fmt.Sprintf("DocumentType(%d)", int(d))
+	}
+	return v[int(d)]
+}
+
 func (d DocumentType) Marshal() ([]byte, error) {
-	return []byte(DocumentTypeValues()[int(d)]), nil
+	v := DocumentTypeValues()
+	if d < 0 || int(d) >= len(v) {
+		return []byte{}, fmt.Errorf("document type not defined: %d", int(d))
+	}
+	return []byte(v[int(d)]), nil
+}
+
+func (d *DocumentType) Unmarshal(data string) error {
+	for i, v := range DocumentTypeValues() {
+		if v == data {
+			*d = DocumentType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("document type not defined: %s", data)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d DocumentType) MarshalText() ([]byte, error) {
+	return d.Marshal()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *DocumentType) UnmarshalText(data []byte) error {
+	return d.Unmarshal(string(data))
 }
 
 const (
@@ -92,63 +241,130 @@ const (
 // PayType
 type PayType int
 
+func PayTypeValues() []string {
+	return []string{"",
+		"Электронно",
+		"Почтой",
+		"Телеграфом",
+		"Срочно",
+	}
+}
+
 func (d PayType) Marshal() ([]byte, error) {
-	v := [][]byte{[]byte("Электронно")}
-	return v[int(d)], nil
+	v := PayTypeValues()
+	if d < 0 || int(d) >= len(v) {
+		return []byte{}, fmt.Errorf("pay type not defined: %d", int(d))
+	}
+	return []byte(v[int(d)]), nil
+}
+
+func (d *PayType) Unmarshal(data string) error {
+	for i, v := range PayTypeValues() {
+		if v == data {
+			*d = PayType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("pay type not defined: %s", data)
+}
+
+// String returns the exchange format value of the pay type.
+func (d PayType) String() string {
+	v := PayTypeValues()
+	if d < 0 || int(d) >= len(v) {
+		return fmt.Sprintf("PayType(%d)", int(d))
+	}
+	return v[int(d)]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d PayType) MarshalText() ([]byte, error) {
+	return d.Marshal()
 }
 
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *PayType) UnmarshalText(data []byte) error {
+	return d.Unmarshal(string(data))
+}
+
+// Pay types. The zero value is PAY_TYPE_NOT_DEFINED, ВидПлатежа is not exported for it.
+// PAY_TYPE_DIG was the zero value before, documents of electronic payments
+// must set it explicitly.
 const (
-	PAY_TYPE_DIG PayType = iota
+	PAY_TYPE_NOT_DEFINED PayType = iota // empty value
+	PAY_TYPE_DIG
+	PAY_TYPE_POST
+	PAY_TYPE_TELEGRAPH
+	PAY_TYPE_URGENT
 )
 
-type BankImportDocument interface {
+// Document gives access to common values of any built-in document.
+type Document interface {
 	GetType() DocumentType
+	GetNum() int
+	GetDate() time.Time
+	GetSum() float64
+	GetCurrency() string
+	GetPayer() Party
+	GetReceiver() Party
+	GetPurpose() string
+	Direction(account string) Direction
+}
+
+type BankImportDocument interface {
+	Document
 }
 
 type BankExportDocument interface {
-	GetType() DocumentType
-	GetDate() time.Time
+	Document
 }
 
 type Account struct {
-	DateFrom     time.Time `bank:"ДатаНачала"`
-	DateTo       time.Time `bank:"ДатаКонца"`
-	Account      string    `bank:"РасчСчет"`
-	BalanceStart float64   `bank:"НачальныйОстаток"`
-	BalanceEnd   float64   `bank:"КонечныйОстаток"`
-	Debet        float64   `bank:"ВсегоПоступило"`
-	Kredit       float64   `bank:"ВсегоСписано"`
+	DateFrom     time.Time `bank:"ДатаНачала" json:"dateFrom"`
+	DateTo       time.Time `bank:"ДатаКонца" json:"dateTo"`
+	Account      string    `bank:"РасчСчет" json:"account"`
+	BalanceStart float64   `bank:"НачальныйОстаток" json:"balanceStart"`
+	BalanceEnd   float64   `bank:"КонечныйОстаток" json:"balanceEnd"`
+	Debet        float64   `bank:"ВсегоПоступило" json:"debet"`
+	Kredit       float64   `bank:"ВсегоСписано" json:"kredit"`
+	Currency     string    `bank:"-" json:"currency,omitempty"` // ISO 4217, derived from Account if empty
 }
 
 // BankExport is the main structure for exporting bank documents.
 type BankImport struct {
-	Version      string               `bank:"ВерсияФормата"`
-	EncodingType EncodingType         `bank:"Кодировка"`
-	Sender       string               `bank:"Отправитель"`
-	CreateDate   time.Time            `bank:"ДатаСоздания"`
-	CreateTime   string               `bank:"ВремяСоздания"`
-	DateFrom     time.Time            `bank:"ДатаНачала"`
-	DateTo       time.Time            `bank:"ДатаКонца"`
-	Account      string               `bank:"РасчСчет"`
-	AccSection   []Account            `bankElemStart:"СекцияРасчСчет" bankElemEnd:"КонецРасчСчет"`
-	Documents    []BankImportDocument `bankElemStart:"СекцияДокумент" bankElemEnd:"КонецДокумента"`
+	Version      string               `bank:"ВерсияФормата" json:"version"`
+	EncodingType EncodingType         `bank:"Кодировка" json:"encodingType"`
+	Sender       string               `bank:"Отправитель" json:"sender"`
+	CreateDate   time.Time            `bank:"ДатаСоздания" json:"createDate"`
+	CreateTime   string               `bank:"ВремяСоздания" json:"createTime"`
+	DateFrom     time.Time            `bank:"ДатаНачала" json:"dateFrom"`
+	DateTo       time.Time            `bank:"ДатаКонца" json:"dateTo"`
+	Account      string               `bank:"РасчСчет" json:"account"`
+	AccSection   []Account            `bankElemStart:"СекцияРасчСчет" bankElemEnd:"КонецРасчСчет" json:"accSection"`
+	Documents    []BankImportDocument `bankElemStart:"СекцияДокумент" bankElemEnd:"КонецДокумента" json:"documents"`
 }
 
+// NewBankImport creates an import with not defined encoding,
+// Unmarshal takes the encoding from the file.
 func NewBankImport() *BankImport {
-	return &BankImport{}
+	return &BankImport{EncodingType: ENCODING_TYPE_NOT_DEFINED}
 }
 
 // BankExport is the main structure for exporting bank documents.
 type BankExport struct {
-	Version       string               `bank:"ВерсияФормата"`
-	EncodingType  EncodingType         `bank:"Кодировка"`
-	Sender        string               `bank:"Отправитель"`
-	CreateDate    time.Time            `bank:"ДатаСоздания"`
-	CreateTime    string               `bank:"ВремяСоздания"`
-	DateFrom      time.Time            `bank:"ДатаНачала"`
-	DateTo        time.Time            `bank:"ДатаКонца"`
-	DocumentTypes []DocumentType       `bankElemStart:"Документ=" bankElemEnd:"\r\n"`
-	Documents     []BankExportDocument `bankElemStart:"СекцияДокумент\r\n" bankElemEnd:"КонецДокумента\r\n"`
+	Version       string               `bank:"ВерсияФормата" json:"version"`
+	EncodingType  EncodingType         `bank:"Кодировка" json:"encodingType"`
+	Sender        string               `bank:"Отправитель" json:"sender"`
+	CreateDate    time.Time            `bank:"ДатаСоздания" json:"createDate"`
+	CreateTime    string               `bank:"ВремяСоздания" json:"createTime"`
+	DateFrom      time.Time            `bank:"ДатаНачала" json:"dateFrom"`
+	DateTo        time.Time            `bank:"ДатаКонца" json:"dateTo"`
+	DocumentTypes []DocumentType       `bankElemStart:"Документ=" bankElemEnd:"\r\n" json:"documentTypes,omitempty"`
+	Documents     []BankExportDocument `bankElemStart:"СекцияДокумент=" bankElemEnd:"КонецДокумента\r\n" json:"documents"`
+	Payer         *PayerProfile        `bank:"-" json:"-"` // empty payer fields of documents are filled from the profile
+	BankDirectory BankDirectory        `bank:"-" json:"-"` // empty bank values of documents are filled from the directory
+	CheckVat      bool                 `bank:"-" json:"-"` // check VAT statements of documents
+	MsgId         string               `bank:"-" json:"-"` // pain.001 message id, generated if empty
 }
 
 func NewBankExport(documents []BankExportDocument) *BankExport {
@@ -162,8 +378,30 @@ func NewBankExport(documents []BankExportDocument) *BankExport {
 	return exp_data
 }
 
-// beforeMarshal adds some values to structure: DocumentTypes, DateFrom, DateTo
-func (e *BankExport) beforeMarshal() {
+// Prepare fills empty payer fields of documents from the payer profile
+// and empty bank values from the bank directory. Documents are changed.
+// It is called by Marshal, it may be called before Validate to check
+// documents as they are exported.
+func (e *BankExport) Prepare() error {
+	e.applyProfile()
+	return e.fillBanks()
+}
+
+// beforeMarshal prepares documents and adds some values to structure:
+// DocumentTypes, DateFrom, DateTo.
+// It also checks that all documents are in one currency,
+// have unique numbers and, if CheckVat is set, VAT statements.
+func (e *BankExport) beforeMarshal() error {
+	if err := e.Prepare(); err != nil {
+		return err
+	}
+	if err := checkDuplicateNumbers(e.Documents); err != nil {
+		return err
+	}
+	if err := e.checkVat(); err != nil {
+		return err
+	}
+	e.DocumentTypes = nil
 	doc_uniq_types := make(map[DocumentType]struct{})
 	for _, doc := range e.Documents {
 		tp := doc.GetType()
@@ -179,6 +417,7 @@ func (e *BankExport) beforeMarshal() {
 			e.DateTo = doc_date
 		}
 	}
+	return checkExportCurrency(e.Documents)
 }
 
 // Marshal exports all documents.
@@ -186,123 +425,198 @@ func (e *BankExport) Marshal() ([]byte, error) {
 	if len(e.Documents) == 0 {
 		return nil, fmt.Errorf("no documents")
 	}
-	e.beforeMarshal()
+	if err := e.beforeMarshal(); err != nil {
+		return nil, err
+	}
 
 	cont, err := marshal(e, "", "")
 	if err != nil {
 		return []byte{}, err
 	}
+	cont = append(cont, []byte(FOOTER+"\n")...)
 	cont, err = e.EncodingType.encode(cont)
 	if err != nil {
 		return []byte{}, err
 	}
 	b := []byte(HEADER + "\n")
 	b = append(b, cont...)
-	b = append(b, []byte(FOOTER+"\n")...)
 	return b, nil
 }
 
 // PPDocument is an export document structure for DOCUMENT_TYPE_PP.
 type PPDocument struct {
-	Num  int       `bank:"Номер"`
-	Date time.Time `bank:"Дата"`
-	Sum  float64   `bank:"Сумма"`
-	// Payer      Payer
-	// Receiver   Receiver
-	Payer            string `bank:"Плательщик"`
-	PayerInn         string `bank:"ПлательщикИНН"`
-	PayerName        string `bank:"Плательщик1"`
-	Payer2           string `bank:"Плательщик2"`
-	Payer3           string `bank:"Плательщик3"`
-	Payer4           string `bank:"Плательщик4"`
-	PayerAccount     string `bank:"ПлательщикРасчСчет"`
-	PayerBankName    string `bank:"ПлательщикБанк1"`
-	PayerBankPlace   string `bank:"ПлательщикБанк2"`
-	PayerBankBik     string `bank:"ПлательщикБИК"`
-	PayerBankAccount string `bank:"ПлательщикКорсчет"`
-
-	Receiver            string  `bank:"Получатель"`
-	ReceiverInn         string  `bank:"ПолучательИНН"`
-	ReceiverName        string  `bank:"Получатель1"`
-	Receiver2           string  `bank:"Получатель2"`
-	Receiver3           string  `bank:"Получатель3"`
-	Receiver4           string  `bank:"Получатель4"`
-	ReceiverAccount     string  `bank:"ПолучательСчет"`
-	ReceiverBankName    string  `bank:"ПолучательБанк1"`
-	ReceiverBankPlace   string  `bank:"ПолучательБанк2"`
-	ReceiverBankBik     string  `bank:"ПолучательБИК"`
-	ReceiverBankAccount string  `bank:"ПолучательКорсчет"`
-	PayType             PayType `bank:"ВидПлатежа"` //вид платежа
-	OplType             string  `bank:"ВидОплаты"`  //вид оплаты
-	Order               int     `bank:"Очередность"`
-	PayComment          string  `bank:"НазначениеПлатежа" lines:"6"`
+	Num      int       `bank:"Номер" json:"num"`
+	Date     time.Time `bank:"Дата" json:"date"`
+	Sum      float64   `bank:"Сумма" json:"sum"`
+	Currency string    `bank:"-" json:"currency,omitempty"` // ISO 4217, derived from accounts if empty
+	Payer    Party     `bankPrefix:"Плательщик" json:"payer"`
+	Receiver Party     `bankPrefix:"Получатель" json:"receiver"`
+
+	PayType    PayType `bank:"ВидПлатежа" bankOmitEmpty:"1" json:"payType"` //вид платежа, not written if not defined
+	OplType    string  `bank:"ВидОплаты" json:"oplType"`                    //вид оплаты
+	Order      int     `bank:"Очередность" json:"order"`
+	PayComment string  `bank:"НазначениеПлатежа" lines:"6" maxLen:"210" linesField:"PayCommentLines" json:"payComment"`
+	// PayComment lines НазначениеПлатежа1..6, filled on import
+	PayCommentLines []string `bank:"-" json:"payCommentLines,omitempty"`
+
+	// budget payment fields, written only when set
+	CompilerStatus string `bank:"СтатусСоставителя" bankOmitEmpty:"1" json:"compilerStatus"`
+	Code           string `bank:"Код" bankOmitEmpty:"1" json:"code"` // УИН
+	KBKValue       string `bank:"ПоказательКБК" bankOmitEmpty:"1" json:"kbkValue"`
+	OKATOValue     string `bank:"ОКАТО" bankOmitEmpty:"1" json:"okatoValue"`
+	OsnovanieValue string `bank:"ПоказательОснования" bankOmitEmpty:"1" json:"osnovanieValue"`
+	PeriodValue    string `bank:"ПоказательПериода" bankOmitEmpty:"1" json:"periodValue"`
+	NomerValue     string `bank:"ПоказательНомера" bankOmitEmpty:"1" json:"nomerValue"`
+	DateValue      string `bank:"ПоказательДаты" bankOmitEmpty:"1" json:"dateValue"`
+	TipValue       string `bank:"ПоказательТипа" bankOmitEmpty:"1" json:"tipValue"`
+
+	// statement fields, not exported to bank
+	KreditDate time.Time `bank:"ДатаСписано" bankOmitEmpty:"1" json:"kreditDate"`
+	DebetDate  time.Time `bank:"ДатаПоступило" bankOmitEmpty:"1" json:"debetDate"`
 }
 
 func (d *PPDocument) GetType() DocumentType {
 	return DOCUMENT_TYPE_PP
 }
 
+func (d *PPDocument) GetNum() int {
+	return d.Num
+}
+
 func (d *PPDocument) GetDate() time.Time {
 	return d.Date
 }
 
+func (d *PPDocument) GetSum() float64 {
+	return d.Sum
+}
+
+// GetCurrency returns the currency of the document,
+// derived from payer or receiver account if not set.
+func (d *PPDocument) GetCurrency() string {
+	return documentCurrency(d.Currency, d.Payer.Account, d.Receiver.Account)
+}
+
+// SumInWords returns the document sum in Russian words in the document currency.
+func (d *PPDocument) SumInWords() (string, error) {
+	return CurrencySumInWords(d.Sum, d.GetCurrency())
+}
+
+func (d *PPDocument) GetPayer() Party {
+	return d.Payer
+}
+
+func (d *PPDocument) GetReceiver() Party {
+	return d.Receiver
+}
+
+func (d *PPDocument) GetPurpose() string {
+	return d.PayComment
+}
+
+// IsBudget returns true for budget payments (СтатусСоставителя is set).
+func (d *PPDocument) IsBudget() bool {
+	return d.CompilerStatus != ""
+}
+
+// MarshalFirmName generates Плательщик/Получатель values
+// if they are not set explicitly.
+func (d *PPDocument) MarshalFirmName(prefix string) ([]byte, error) {
+	switch prefix {
+	case PAYER_PREFIX:
+		return []byte(d.Payer.FirmName(d.IsBudget())), nil
+	case RECEIVER_PREFIX:
+		return []byte(d.Receiver.FirmName(d.IsBudget())), nil
+	}
+	return []byte{}, fmt.Errorf("unknown firm prefix %s", prefix)
+}
+
+// Direction returns money direction of the document relative to the account.
+func (d *PPDocument) Direction(account string) Direction {
+	return documentDirection(account, d.Payer.Account, d.Receiver.Account, d.KreditDate, d.DebetDate)
+}
+
 // BankOrderDocument is an import document structure for DOCUMENT_TYPE_BANK_ORDER.
 type BankOrderDocument struct {
-	Num           int       `bank:"Номер"`
-	Date          time.Time `bank:"Дата"`
-	Sum           float64   `bank:"Сумма"`
-	ReceitDate    time.Time `bank:"КвитанцияДата"`
-	ReceitTime    string    `bank:"КвитанцияВремя"`
-	ReceitComment string    `bank:"КвитанцияСодержание"` // combined value
-
-	Payer            string `bank:"Плательщик"`
-	PayerInn         string `bank:"ПлательщикИНН"`
-	PayerName        string `bank:"Плательщик1"`
-	Payer2           string `bank:"Плательщик2"`
-	Payer3           string `bank:"Плательщик3"`
-	Payer4           string `bank:"Плательщик4"`
-	PayerAccount     string `bank:"ПлательщикРасчСчет"`
-	PayerBankName    string `bank:"ПлательщикБанк1"`
-	PayerBankPlace   string `bank:"ПлательщикБанк2"`
-	PayerBankBik     string `bank:"ПлательщикБИК"`
-	PayerBankAccount string `bank:"ПлательщикКорсчет"`
-
-	Receiver            string `bank:"Получатель"`
-	ReceiverInn         string `bank:"ПолучательИНН"`
-	Receiver2           string `bank:"Получатель2"`
-	Receiver3           string `bank:"Получатель3"`
-	Receiver4           string `bank:"Получатель4"`
-	ReceiverAccount     string `bank:"ПолучательСчет"`
-	ReceiverBankName    string `bank:"ПолучательБанк1"`
-	ReceiverBankPlace   string `bank:"ПолучательБанк2"`
-	ReceiverBankBik     string `bank:"ПолучательБИК"`
-	ReceiverBankAccount string `bank:"ПолучательКорсчет"`
-
-	KreditDate    time.Time `bank:"ДатаСписано"`
-	DebetDate     time.Time `bank:"ДатаПоступило"`
-	PayType       PayType   `bank:"ВидПлатежа"` //вид платежа
-	Code          string    `bank:"Код"`
-	PayDirectCode string    `bank:"КодНазПлатежа"`
-
-	PayComment string `bank:"НазначениеПлатежа"`
-
-	KBKValue         string `bank:"ПоказательКБК"`
-	OKATOValue       string `bank:"ОКАТО"`
-	OsnovanieValue   string `bank:"ПоказательОснования"`
-	PeriodValue      string `bank:"ПоказательПериода"`
-	NomerValue       string `bank:"ПоказательНомера"`
-	DateValue        string `bank:"ПоказательДаты"`
-	TipValue         string `bank:"ПоказательТипа"`
-	Order            int    `bank:"Очередность"`
-	AcceptTerm       string `bank:"СрокАкцепта"`
-	AccredType       string `bank:"ВидАккредитива"`
-	PayTerm          string `bank:"СрокПлатежа"`
-	PayCond1         string `bank:"УсловиеОплаты1"`
-	PayCond2         string `bank:"УсловиеОплаты2"`
-	PayCond3         string `bank:"УсловиеОплаты3"`
-	SupplierOrderNum string `bank:"НомерСчетаПоставщика"`
+	Num           int       `bank:"Номер" json:"num"`
+	Date          time.Time `bank:"Дата" json:"date"`
+	Sum           float64   `bank:"Сумма" json:"sum"`
+	Currency      string    `bank:"-" json:"currency,omitempty"` // ISO 4217, derived from accounts if empty
+	ReceitDate    time.Time `bank:"КвитанцияДата" json:"receitDate"`
+	ReceitTime    string    `bank:"КвитанцияВремя" json:"receitTime"`
+	ReceitComment string    `bank:"КвитанцияСодержание" json:"receitComment"` // combined value
+
+	Payer    Party `bankPrefix:"Плательщик" json:"payer"`
+	Receiver Party `bankPrefix:"Получатель" json:"receiver"`
+
+	KreditDate     time.Time `bank:"ДатаСписано" json:"kreditDate"`
+	DebetDate      time.Time `bank:"ДатаПоступило" json:"debetDate"`
+	PayType        PayType   `bank:"ВидПлатежа" json:"payType"` //вид платежа
+	Code           string    `bank:"Код" json:"code"`
+	PayDirectCode  string    `bank:"КодНазПлатежа" json:"payDirectCode"`
+	CompilerStatus string    `bank:"СтатусСоставителя" json:"compilerStatus"`
+
+	PayComment string `bank:"НазначениеПлатежа" lines:"6" linesField:"PayCommentLines" json:"payComment"`
+	// PayComment lines НазначениеПлатежа1..6
+	PayCommentLines []string `bank:"-" json:"payCommentLines,omitempty"`
+
+	KBKValue         string `bank:"ПоказательКБК" json:"kbkValue"`
+	OKATOValue       string `bank:"ОКАТО" json:"okatoValue"`
+	OsnovanieValue   string `bank:"ПоказательОснования" json:"osnovanieValue"`
+	PeriodValue      string `bank:"ПоказательПериода" json:"periodValue"`
+	NomerValue       string `bank:"ПоказательНомера" json:"nomerValue"`
+	DateValue        string `bank:"ПоказательДаты" json:"dateValue"`
+	TipValue         string `bank:"ПоказательТипа" json:"tipValue"`
+	Order            int    `bank:"Очередность" json:"order"`
+	AcceptTerm       string `bank:"СрокАкцепта" json:"acceptTerm"`
+	AccredType       string `bank:"ВидАккредитива" json:"accredType"`
+	PayTerm          string `bank:"СрокПлатежа" json:"payTerm"`
+	PayCond1         string `bank:"УсловиеОплаты1" json:"payCond1"`
+	PayCond2         string `bank:"УсловиеОплаты2" json:"payCond2"`
+	PayCond3         string `bank:"УсловиеОплаты3" json:"payCond3"`
+	SupplierOrderNum string `bank:"НомерСчетаПоставщика" json:"supplierOrderNum"`
 }
 
 func (d *BankOrderDocument) GetType() DocumentType {
 	return DOCUMENT_TYPE_BANK_ORDER
 }
+
+func (d *BankOrderDocument) GetNum() int {
+	return d.Num
+}
+
+func (d *BankOrderDocument) GetDate() time.Time {
+	return d.Date
+}
+
+func (d *BankOrderDocument) GetSum() float64 {
+	return d.Sum
+}
+
+// GetCurrency returns the currency of the document,
+// derived from payer or receiver account if not set.
+func (d *BankOrderDocument) GetCurrency() string {
+	return documentCurrency(d.Currency, d.Payer.Account, d.Receiver.Account)
+}
+
+// SumInWords returns the document sum in Russian words in the document currency.
+func (d *BankOrderDocument) SumInWords() (string, error) {
+	return CurrencySumInWords(d.Sum, d.GetCurrency())
+}
+
+func (d *BankOrderDocument) GetPayer() Party {
+	return d.Payer
+}
+
+func (d *BankOrderDocument) GetReceiver() Party {
+	return d.Receiver
+}
+
+func (d *BankOrderDocument) GetPurpose() string {
+	return d.PayComment
+}
+
+// Direction returns money direction of the document relative to the account.
+func (d *BankOrderDocument) Direction(account string) Direction {
+	return documentDirection(account, d.Payer.Account, d.Receiver.Account, d.KreditDate, d.DebetDate)
+}
@@ -1,7 +1,14 @@
 package clbnk
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -33,40 +40,56 @@ const (
 
 func TestExport(t *testing.T) {
 	documents := []BankExportDocument{&PPDocument{Num: 1,
-		Date:              time.Now(),
-		Sum:               175000,
-		PayerName:         `ООО "Рога и Копыта"`,
-		PayerInn:          "1234567891",
-		PayerAccount:      "12345678901234567890",
-		PayerBankName:     "Объёббанк ОАО",
-		PayerBankPlace:    "г. Москва",
-		PayerBankBik:      "123456789",
-		PayerBankAccount:  "12345678901234567890",
-		ReceiverName:      `ИП Иванов А.А.`,
-		ReceiverInn:       "111122223344",
-		ReceiverAccount:   "12345678901234567890",
-		ReceiverBankName:  "КакойтоБанк ОАО",
-		ReceiverBankPlace: "г. Москва", ReceiverBankBik: "123456789", ReceiverBankAccount: "12345678901234567890", PayType: PAY_TYPE_DIG, OplType: "01",
+		Date: time.Now(),
+		Sum:  175000,
+		Payer: Party{Name: `ООО "Рога и Копыта"`,
+			Inn:     "1234567891",
+			Account: "12345678901234567890",
+			Bank: BankInfo{Name: "Объёббанк ОАО",
+				Place:   "г. Москва",
+				Bik:     "123456789",
+				Account: "12345678901234567890",
+			},
+		},
+		Receiver: Party{Name: `ИП Иванов А.А.`,
+			Inn:     "111122223344",
+			Account: "12345678901234567890",
+			Bank: BankInfo{Name: "КакойтоБанк ОАО",
+				Place:   "г. Москва",
+				Bik:     "123456789",
+				Account: "12345678901234567890",
+			},
+		},
+		PayType:    PAY_TYPE_DIG,
+		OplType:    "01",
 		Order:      5,
 		PayComment: "За товары, по счету №125 на сумму 175000-00",
 	},
 		&PPDocument{Num: 2,
-			Date:             time.Now(),
-			Sum:              375.25,
-			PayerName:        `ООО "Рога и Копыта"`,
-			PayerInn:         "1234567891",
-			PayerAccount:     "12345678901234567890",
-			PayerBankName:    "КакойтоБанк ОАО",
-			PayerBankPlace:   "г. Москва",
-			PayerBankBik:     "123456789",
-			PayerBankAccount: "12345678901234567890",
-			ReceiverName:     `ИП Иванов А.А.`,
-			ReceiverInn:      "111122223344",
-			ReceiverAccount:  "12345678901234567890",
-			PayType:          PAY_TYPE_DIG,
-			OplType:          "01",
-			Order:            5,
-			PayComment:       "За товары, по счету №777 на сумму 375-25\nPlus NDS 111-16",
+			Date: time.Now(),
+			Sum:  375.25,
+			Payer: Party{Name: `ООО "Рога и Копыта"`,
+				Inn:     "1234567891",
+				Account: "12345678901234567890",
+				Bank: BankInfo{Name: "КакойтоБанк ОАО",
+					Place:   "г. Москва",
+					Bik:     "123456789",
+					Account: "12345678901234567890",
+				},
+			},
+			Receiver: Party{Name: `ИП Иванов А.А.`,
+				Inn:     "111122223344",
+				Account: "12345678901234567890",
+				Bank: BankInfo{Name: "КакойтоБанк ОАО",
+					Place:   "г. Москва",
+					Bik:     "123456789",
+					Account: "12345678901234567890",
+				},
+			},
+			PayType:    PAY_TYPE_DIG,
+			OplType:    "01",
+			Order:      5,
+			PayComment: "За товары, по счету №777 на сумму 375-25\nPlus NDS 111-16",
 		},
 	}
 
@@ -89,6 +112,15 @@ func TestExport(t *testing.T) {
 	if err := f.Close(); err != nil {
 		panic(err)
 	}
+
+	//exported file is valid as the statement of the validate command
+	imp := NewBankImport()
+	if err := imp.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal of exported file failed: %v", err)
+	}
+	if err := imp.Validate(); err != nil {
+		t.Fatalf("Validate of exported file failed: %v", err)
+	}
 }
 
 func TestImport(t *testing.T) {
@@ -110,20 +142,20 @@ func TestImport(t *testing.T) {
 		t.Fatal("document[0] must be of type BankOrderDocument")
 	}
 
-	if doc.PayerAccount != TEST_DOC0_PAYER_ACC {
-		t.Fatalf("document[0] payer account, expected %s, got %s", TEST_DOC0_PAYER_ACC, doc.PayerAccount)
+	if doc.Payer.Account != TEST_DOC0_PAYER_ACC {
+		t.Fatalf("document[0] payer account, expected %s, got %s", TEST_DOC0_PAYER_ACC, doc.Payer.Account)
 	}
-	if doc.PayerInn != TEST_DOC0_PAYER_INN {
-		t.Fatalf("document[0] payer inn, expected %s, got %s", TEST_DOC0_PAYER_INN, doc.PayerInn)
+	if doc.Payer.Inn != TEST_DOC0_PAYER_INN {
+		t.Fatalf("document[0] payer inn, expected %s, got %s", TEST_DOC0_PAYER_INN, doc.Payer.Inn)
 	}
-	if doc.PayerName != TEST_DOC0_PAYER_NAME {
-		t.Fatalf("document[0] payer name, expected %s, got %s", TEST_DOC0_PAYER_NAME, doc.PayerName)
+	if doc.Payer.Name != TEST_DOC0_PAYER_NAME {
+		t.Fatalf("document[0] payer name, expected %s, got %s", TEST_DOC0_PAYER_NAME, doc.Payer.Name)
 	}
-	if doc.ReceiverInn != TEST_DOC0_REC_INN {
-		t.Fatalf("document[0] receiver inn, expected %s, got %s", TEST_DOC0_REC_INN, doc.ReceiverInn)
+	if doc.Receiver.Inn != TEST_DOC0_REC_INN {
+		t.Fatalf("document[0] receiver inn, expected %s, got %s", TEST_DOC0_REC_INN, doc.Receiver.Inn)
 	}
-	if doc.ReceiverAccount != TEST_DOC0_REC_ACC {
-		t.Fatalf("document[0] receiver account, expected %s, got %s", TEST_DOC0_REC_ACC, doc.ReceiverAccount)
+	if doc.Receiver.Account != TEST_DOC0_REC_ACC {
+		t.Fatalf("document[0] receiver account, expected %s, got %s", TEST_DOC0_REC_ACC, doc.Receiver.Account)
 	}
 	if doc.Sum != TEST_DOC0_SUM {
 		t.Fatalf("document[0] sum, expected %f, got %f", TEST_DOC0_SUM, doc.Sum)
@@ -137,20 +169,20 @@ func TestImport(t *testing.T) {
 	if !ok {
 		t.Fatal("document[1] must be of type PPDocument")
 	}
-	if doc1.PayerAccount != TEST_DOC1_PAYER_ACC {
-		t.Fatalf("document[1] payer account, expected %s, got %s", TEST_DOC1_PAYER_ACC, doc1.PayerAccount)
+	if doc1.Payer.Account != TEST_DOC1_PAYER_ACC {
+		t.Fatalf("document[1] payer account, expected %s, got %s", TEST_DOC1_PAYER_ACC, doc1.Payer.Account)
 	}
-	if doc1.PayerInn != TEST_DOC1_PAYER_INN {
-		t.Fatalf("document[1] payer inn, expected %s, got %s", TEST_DOC1_PAYER_INN, doc1.PayerInn)
+	if doc1.Payer.Inn != TEST_DOC1_PAYER_INN {
+		t.Fatalf("document[1] payer inn, expected %s, got %s", TEST_DOC1_PAYER_INN, doc1.Payer.Inn)
 	}
-	if doc1.PayerName != TEST_DOC1_PAYER_NAME {
-		t.Fatalf("document[1] payer name, expected %s, got %s", TEST_DOC1_PAYER_NAME, doc1.PayerName)
+	if doc1.Payer.Name != TEST_DOC1_PAYER_NAME {
+		t.Fatalf("document[1] payer name, expected %s, got %s", TEST_DOC1_PAYER_NAME, doc1.Payer.Name)
 	}
-	if doc1.ReceiverInn != TEST_DOC1_REC_INN {
-		t.Fatalf("document[1] receiver inn, expected %s, got %s", TEST_DOC1_REC_INN, doc1.ReceiverInn)
+	if doc1.Receiver.Inn != TEST_DOC1_REC_INN {
+		t.Fatalf("document[1] receiver inn, expected %s, got %s", TEST_DOC1_REC_INN, doc1.Receiver.Inn)
 	}
-	if doc1.ReceiverAccount != TEST_DOC1_REC_ACC {
-		t.Fatalf("document[1] receiver account, expected %s, got %s", TEST_DOC1_REC_ACC, doc1.ReceiverAccount)
+	if doc1.Receiver.Account != TEST_DOC1_REC_ACC {
+		t.Fatalf("document[1] receiver account, expected %s, got %s", TEST_DOC1_REC_ACC, doc1.Receiver.Account)
 	}
 	if doc1.Sum != TEST_DOC1_SUM {
 		t.Fatalf("document[1] sum, expected %f, got %f", TEST_DOC1_SUM, doc1.Sum)
@@ -159,22 +191,663 @@ func TestImport(t *testing.T) {
 	if !ok {
 		t.Fatal("document[2] must be of type PPDocument")
 	}
-	if doc2.PayerAccount != TEST_DOC2_PAYER_ACC {
-		t.Fatalf("document[2] payer account, expected %s, got %s", TEST_DOC2_PAYER_ACC, doc2.PayerAccount)
+	if doc2.Payer.Account != TEST_DOC2_PAYER_ACC {
+		t.Fatalf("document[2] payer account, expected %s, got %s", TEST_DOC2_PAYER_ACC, doc2.Payer.Account)
 	}
-	if doc2.PayerInn != TEST_DOC2_PAYER_INN {
-		t.Fatalf("document[2] payer inn, expected %s, got %s", TEST_DOC2_PAYER_INN, doc2.PayerInn)
+	if doc2.Payer.Inn != TEST_DOC2_PAYER_INN {
+		t.Fatalf("document[2] payer inn, expected %s, got %s", TEST_DOC2_PAYER_INN, doc2.Payer.Inn)
 	}
-	if doc2.PayerName != TEST_DOC2_PAYER_NAME {
-		t.Fatalf("document[2] payer name, expected %s, got %s", TEST_DOC2_PAYER_NAME, doc2.PayerName)
+	if doc2.Payer.Name != TEST_DOC2_PAYER_NAME {
+		t.Fatalf("document[2] payer name, expected %s, got %s", TEST_DOC2_PAYER_NAME, doc2.Payer.Name)
 	}
-	if doc2.ReceiverInn != TEST_DOC2_REC_INN {
-		t.Fatalf("document[2] receiver inn, expected %s, got %s", TEST_DOC2_REC_INN, doc2.ReceiverInn)
+	if doc2.Receiver.Inn != TEST_DOC2_REC_INN {
+		t.Fatalf("document[2] receiver inn, expected %s, got %s", TEST_DOC2_REC_INN, doc2.Receiver.Inn)
 	}
-	if doc2.ReceiverAccount != TEST_DOC2_REC_ACC {
-		t.Fatalf("document[2] receiver account, expected %s, got %s", TEST_DOC2_REC_ACC, doc2.ReceiverAccount)
+	if doc2.Receiver.Account != TEST_DOC2_REC_ACC {
+		t.Fatalf("document[2] receiver account, expected %s, got %s", TEST_DOC2_REC_ACC, doc2.Receiver.Account)
 	}
 	if doc2.Sum != TEST_DOC2_SUM {
 		t.Fatalf("document[2] sum, expected %f, got %f", TEST_DOC2_SUM, doc2.Sum)
 	}
 }
+
+func TestDirection(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if dir := imp.Documents[0].Direction(TEST_DOC0_PAYER_ACC); dir != DIRECTION_OUTGOING {
+		t.Fatalf("document[0] direction, expected %d, got %d", DIRECTION_OUTGOING, dir)
+	}
+	if dir := imp.Documents[1].Direction(TEST_DOC1_REC_ACC); dir != DIRECTION_INCOMING {
+		t.Fatalf("document[1] direction, expected %d, got %d", DIRECTION_INCOMING, dir)
+	}
+	if dir := imp.Documents[1].Direction(""); dir != DIRECTION_OUTGOING {
+		t.Fatalf("document[1] direction by date, expected %d, got %d", DIRECTION_OUTGOING, dir)
+	}
+	if docs := imp.Outgoing(""); len(docs) != 2 {
+		t.Fatalf("outgoing document count, expected 2, got %d", len(docs))
+	}
+	if docs := imp.Incoming(""); len(docs) != 0 {
+		t.Fatalf("incoming document count, expected 0, got %d", len(docs))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	views := imp.Filter("", FilterInn(TEST_DOC2_REC_INN), FilterSumRange(100000, 0))
+	if len(views) != 1 {
+		t.Fatalf("filtered view count, expected 1, got %d", len(views))
+	}
+	if views[0].Sum != TEST_DOC2_SUM {
+		t.Fatalf("filtered view sum, expected %f, got %f", TEST_DOC2_SUM, views[0].Sum)
+	}
+	if views[0].CounterpartyInn() != TEST_DOC2_REC_INN {
+		t.Fatalf("filtered view counterparty inn, expected %s, got %s", TEST_DOC2_REC_INN, views[0].CounterpartyInn())
+	}
+	views = imp.Filter("",
+		FilterDateRange(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), time.Time{}),
+		FilterPurpose(regexp.MustCompile(`заказу клиента №\d+`)),
+	)
+	if len(views) != 2 {
+		t.Fatalf("filtered view count by date and purpose, expected 2, got %d", len(views))
+	}
+}
+
+func TestDocumentAccessors(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	var doc Document = imp.Documents[0]
+	if doc.GetDate() != time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("document[0] date, expected 01.01.2024, got %v", doc.GetDate())
+	}
+	if doc.GetPayer().Inn != TEST_DOC0_PAYER_INN {
+		t.Fatalf("document[0] payer inn, expected %s, got %s", TEST_DOC0_PAYER_INN, doc.GetPayer().Inn)
+	}
+	if doc.GetReceiver().Account != TEST_DOC0_REC_ACC {
+		t.Fatalf("document[0] receiver account, expected %s, got %s", TEST_DOC0_REC_ACC, doc.GetReceiver().Account)
+	}
+	if doc.GetPayer().Kpp != "770401001" || doc.GetPayer().Bank.Bik != "044525411" {
+		t.Fatalf("document[0] payer kpp/bik, got %s/%s", doc.GetPayer().Kpp, doc.GetPayer().Bank.Bik)
+	}
+	if doc.GetReceiver().Firm != `7702070139, ФИЛИАЛ "ЦЕНТРАЛЬНЫЙ" БАНКА ВТБ (ПАО)` {
+		t.Fatalf("document[0] receiver firm, got %s", doc.GetReceiver().Firm)
+	}
+	if doc.GetSum() != TEST_DOC0_SUM {
+		t.Fatalf("document[0] sum, expected %f, got %f", TEST_DOC0_SUM, doc.GetSum())
+	}
+}
+
+func TestFirmName(t *testing.T) {
+	doc := &PPDocument{Num: 1,
+		Date:     time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		Sum:      100,
+		Payer:    Party{Name: `ООО "Рога и Копыта"`, Inn: "1234567891", Account: "40702810000000077777"},
+		Receiver: Party{Firm: "ИП Иванов", Name: "ИП Иванов А.А.", Inn: "111122223344"},
+	}
+	b, err := marshal(doc, "", "")
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(b), "Плательщик=ИНН 1234567891 ООО \"Рога и Копыта\"\r\n") {
+		t.Fatalf("payer firm name not generated:\n%s", b)
+	}
+	if !strings.Contains(string(b), "Получатель=ИП Иванов\r\n") {
+		t.Fatalf("receiver firm name must not be changed:\n%s", b)
+	}
+
+	doc.Payer.Name2 = "40702810000000011111"
+	doc.Payer.Name3 = "ПАО Сбербанк"
+	doc.Payer.Name4 = "г. Москва"
+	doc.Payer.Bank.Name = "ПАО Сбербанк"
+	if v := doc.Payer.FirmName(doc.IsBudget()); v != `ИНН 1234567891 ООО "Рога и Копыта"` {
+		t.Fatalf("payer firm name with account at the same bank, got %s", v)
+	}
+	doc.Payer.Bank.Name = "АО Банк"
+	if v := doc.Payer.FirmName(doc.IsBudget()); v != `ИНН 1234567891 ООО "Рога и Копыта" р/с 40702810000000011111 в ПАО Сбербанк г. Москва` {
+		t.Fatalf("payer firm name with account at another bank, got %s", v)
+	}
+
+	doc.CompilerStatus = "01"
+	doc.Payer.Bank.Name = "ПАО Сбербанк"
+	if v := doc.Payer.FirmName(doc.IsBudget()); v != `ООО "Рога и Копыта" р/с 40702810000000011111 в ПАО Сбербанк г. Москва` {
+		t.Fatalf("budget payer firm name, got %s", v)
+	}
+}
+
+func TestPayType(t *testing.T) {
+	for _, v := range []string{"Электронно", "Почтой", "Телеграфом", "Срочно", ""} {
+		var tp PayType
+		if err := tp.Unmarshal(v); err != nil {
+			t.Fatalf("PayType.Unmarshal(%q) failed: %v", v, err)
+		}
+		b, err := tp.Marshal()
+		if err != nil {
+			t.Fatalf("PayType.Marshal() failed: %v", err)
+		}
+		if string(b) != v {
+			t.Fatalf("PayType marshal, expected %q, got %q", v, b)
+		}
+	}
+	if _, err := PayType(100).Marshal(); err == nil {
+		t.Fatal("PayType.Marshal() must fail for out of range value")
+	}
+
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if tp := imp.Documents[1].(*PPDocument).PayType; tp != PAY_TYPE_NOT_DEFINED {
+		t.Fatalf("document[1] pay type, expected %d, got %d", PAY_TYPE_NOT_DEFINED, tp)
+	}
+
+	//zero value is not PAY_TYPE_DIG, electronic payment must be set explicitly
+	doc := &PPDocument{Num: 1, Date: time.Now(), Sum: 1}
+	if doc.PayType != PAY_TYPE_NOT_DEFINED || PAY_TYPE_DIG == 0 {
+		t.Fatalf("zero pay type, expected %d, got %d", PAY_TYPE_NOT_DEFINED, doc.PayType)
+	}
+	b, err := marshal(doc, "", "")
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if strings.Contains(string(b), "ВидПлатежа=") {
+		t.Fatalf("not defined pay type must not be written:\n%s", b)
+	}
+	doc.PayType = PAY_TYPE_DIG
+	if b, err = marshal(doc, "", ""); err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(b), "ВидПлатежа=Электронно\r\n") {
+		t.Fatalf("pay type not written:\n%s", b)
+	}
+}
+
+func TestEnumMarshalErrors(t *testing.T) {
+	exp := NewBankExport([]BankExportDocument{&PPDocument{Num: 1, Date: time.Now(), Sum: 1}})
+	exp.EncodingType = ENCODING_TYPE_NOT_DEFINED
+	if _, err := exp.Marshal(); err == nil {
+		t.Fatal("Marshal() must fail for undefined encoding")
+	}
+	exp = NewBankExport([]BankExportDocument{&PPDocument{Num: 1, Date: time.Now(), Sum: 1, PayType: PayType(-1)}})
+	if _, err := exp.Marshal(); err == nil {
+		t.Fatal("Marshal() must fail for undefined pay type")
+	}
+	if _, err := DocumentType(10).MarshalText(); err == nil {
+		t.Fatal("DocumentType.MarshalText() must fail for undefined type")
+	}
+	var tp DocumentType
+	if err := tp.UnmarshalText([]byte("Банковский ордер")); err != nil || tp != DOCUMENT_TYPE_BANK_ORDER {
+		t.Fatalf("DocumentType.UnmarshalText() failed: %v", err)
+	}
+	if s := ENCODING_TYPE_DOS.String(); s != ENCODING_DOS {
+		t.Fatalf("EncodingType.String(), expected %s, got %s", ENCODING_DOS, s)
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	lines, err := WrapLines("За товары, по счету №777 на сумму 375-25 В том числе НДС (20%) 62-54", 6, 35)
+	if err != nil {
+		t.Fatalf("WrapLines() failed: %v", err)
+	}
+	expected := []string{"За товары, по счету №777 на сумму", "375-25", "В том числе НДС (20%) 62-54"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Fatalf("WrapLines(), expected %q, got %q", expected, lines)
+	}
+	if _, err := WrapLines(strings.Repeat("слово ", 40), 6, 35); err == nil {
+		t.Fatal("WrapLines() must fail for too long value")
+	}
+
+	doc := &PPDocument{Num: 1, Date: time.Now(), Sum: 1, PayComment: strings.Repeat("а", PURPOSE_MAX_LEN+1)}
+	if _, err := marshal(doc, "", ""); err == nil {
+		t.Fatal("marshal must fail for too long payment purpose")
+	}
+
+	//long words waste line ends, the value is split regardless of words
+	purpose := []rune(strings.Repeat("абвгдежзийклмнопрс ", 12))[:PURPOSE_MAX_LEN]
+	doc.PayComment = string(purpose)
+	b, err := marshal(doc, "", "")
+	if err != nil {
+		t.Fatalf("marshal of payment purpose with %d characters failed: %v", PURPOSE_MAX_LEN, err)
+	}
+	if !strings.Contains(string(b), "НазначениеПлатежа6="+string(purpose[175:])+"\r\n") {
+		t.Fatalf("payment purpose not split by width:\n%s", b)
+	}
+}
+
+func TestImportLines(t *testing.T) {
+	data := strings.Join([]string{HEADER,
+		"ВерсияФормата=1.03",
+		"Кодировка=Windows",
+		"СекцияДокумент=Банковский ордер",
+		"Номер=1",
+		"НазначениеПлатежа=",
+		"НазначениеПлатежа1=Оплата по счету №1",
+		"НазначениеПлатежа2=НДС не облагается",
+		"НазначениеПлатежа3=",
+		"КонецДокумента",
+		FOOTER,
+	}, "\r\n")
+	b, err := ENCODING_TYPE_WIN.encode([]byte(data))
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	doc := imp.Documents[0].(*BankOrderDocument)
+	if doc.PayComment != "Оплата по счету №1 НДС не облагается" {
+		t.Fatalf("pay comment from lines, got %q", doc.PayComment)
+	}
+	if len(doc.PayCommentLines) != 2 || doc.PayCommentLines[1] != "НДС не облагается" {
+		t.Fatalf("pay comment lines, got %q", doc.PayCommentLines)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	doc := &PPDocument{Num: 12,
+		Date:       time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		Sum:        175000,
+		Payer:      Party{Name: `ООО "Рога и Копыта"`, Inn: "1234567891", Account: "40702810000000077777"},
+		Receiver:   Party{Name: "ИП Иванов А.А.", Inn: "111122223344"},
+		PayComment: "За товары, по счету №125 на сумму 175000-00",
+	}
+	var buf strings.Builder
+	if err := doc.RenderHTML(&buf); err != nil {
+		t.Fatalf("RenderHTML() failed: %v", err)
+	}
+	html := buf.String()
+	for _, s := range []string{"0401060", "ПЛАТЕЖНОЕ ПОРУЧЕНИЕ № 12", "10.06.2024", "175000=",
+		"Сто семьдесят пять тысяч рублей 00 копеек", "ИНН 1234567891<", "ООО &#34;Рога и Копыта&#34;",
+	} {
+		if !strings.Contains(html, s) {
+			t.Fatalf("rendered form does not contain %q", s)
+		}
+	}
+	//INN is printed in its own cell only
+	if strings.Contains(html, "ИНН 1234567891 ООО") || strings.Count(html, "1234567891") != 1 {
+		t.Fatal("rendered form must not repeat INN in the name cell")
+	}
+
+	//amount in words in the document currency
+	doc.Payer.Account = "40702840000000077777"
+	buf.Reset()
+	if err := doc.RenderHTML(&buf); err != nil || !strings.Contains(buf.String(), "Сто семьдесят пять тысяч долларов США 00 центов") {
+		t.Fatalf("RenderHTML() of USD document must print dollars, got %v", err)
+	}
+	doc.Currency = "XXX"
+	if err := doc.RenderHTML(io.Discard); err == nil {
+		t.Fatal("RenderHTML() of currency without amount words must fail")
+	}
+}
+
+// testJSONRoundTrip checks that the import is the same after JSON marshaling.
+func testJSONRoundTrip(t *testing.T, imp *BankImport) {
+	t.Helper()
+	b, err := json.Marshal(imp)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	imp2 := &BankImport{}
+	if err := json.Unmarshal(b, imp2); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if !reflect.DeepEqual(imp, imp2) {
+		t.Fatalf("JSON round trip failed:\n%+v\n%+v", imp, imp2)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	b, err := json.Marshal(imp)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	if !strings.Contains(string(b), `{"type":"Банковский ордер","num":69147,`) {
+		t.Fatalf("document type discriminator not found: %s", b)
+	}
+	imp2 := NewBankImport()
+	if err := json.Unmarshal(b, imp2); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if !reflect.DeepEqual(imp, imp2) {
+		t.Fatalf("JSON round trip failed:\n%+v\n%+v", imp, imp2)
+	}
+
+	//value and field of the type
+	b, err = json.Marshal(struct{ Import BankImport }{*imp})
+	if err != nil {
+		t.Fatalf("json.Marshal() of value failed: %v", err)
+	}
+	var imp_val struct{ Import BankImport }
+	if err := json.Unmarshal(b, &imp_val); err != nil {
+		t.Fatalf("json.Unmarshal() of value failed: %v", err)
+	}
+	if !reflect.DeepEqual(*imp, imp_val.Import) {
+		t.Fatalf("JSON round trip of value failed:\n%+v\n%+v", *imp, imp_val.Import)
+	}
+
+	exp := NewBankExport([]BankExportDocument{&PPDocument{Num: 1,
+		Date:    time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		Sum:     375.25,
+		Payer:   Party{Name: `ООО "Рога и Копыта"`, Inn: "1234567891"},
+		PayType: PAY_TYPE_DIG,
+	}})
+	b, err = json.Marshal(exp)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	exp2 := &BankExport{}
+	if err := json.Unmarshal(b, exp2); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	doc, ok := exp2.Documents[0].(*PPDocument)
+	if !ok || doc.Sum != 375.25 || doc.PayType != PAY_TYPE_DIG || doc.Payer.Inn != "1234567891" {
+		t.Fatalf("export JSON round trip failed: %s", b)
+	}
+	if b, err = json.Marshal(*exp); err != nil {
+		t.Fatalf("json.Marshal() of export value failed: %v", err)
+	}
+	if !strings.Contains(string(b), `{"type":"Платежное поручение","num":1,`) {
+		t.Fatalf("export value document type discriminator not found: %s", b)
+	}
+
+	//import without encoding
+	b, err = json.Marshal(NewBankImport())
+	if err != nil {
+		t.Fatalf("json.Marshal() of empty import failed: %v", err)
+	}
+	if !strings.Contains(string(b), `"encodingType":""`) {
+		t.Fatalf("undefined encoding must be an empty string: %s", b)
+	}
+	imp2 = &BankImport{}
+	if err := json.Unmarshal(b, imp2); err != nil || imp2.EncodingType != ENCODING_TYPE_NOT_DEFINED {
+		t.Fatalf("json.Unmarshal() of empty import, got %v %v", imp2.EncodingType, err)
+	}
+}
+
+func TestStatementTable(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	tbl := NewStatementTable()
+	tbl.Balances = true
+	var buf bytes.Buffer
+	if err := tbl.WriteCSV(&buf, imp); err != nil {
+		t.Fatalf("WriteCSV() failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1+TEST_DOC_COUNT+2 {
+		t.Fatalf("CSV line count, expected %d, got %d", 1+TEST_DOC_COUNT+2, len(lines))
+	}
+	if lines[2] != `01.01.2024;69147;Списание;"ФИЛИАЛ ""ЦЕНТРАЛЬНЫЙ"" БАНКА ВТБ (ПАО)";7702070139;47422810119484000074;6936.00;"Оплата стоимости пакета услуг ""Всё по делу"" за период с 01/01/2024 по 31/12/2024 согласно тарифам Банка (п. 17.2.1.). НДС не облагается. "` {
+		t.Fatalf("CSV document line, got %s", lines[2])
+	}
+
+	buf.Reset()
+	if err := tbl.WriteXLSX(&buf, imp); err != nil {
+		t.Fatalf("WriteXLSX() failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() failed: %v", err)
+	}
+	if len(zr.File) != 5 {
+		t.Fatalf("XLSX file count, expected 5, got %d", len(zr.File))
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	for acc, cur := range map[string]string{TEST_DOC0_PAYER_ACC: CURRENCY_RUB,
+		"40702840000000001234": CURRENCY_USD,
+		"40702978000000001234": CURRENCY_EUR,
+		"40702156000000001234": CURRENCY_CNY,
+		"40702999000000001234": "",
+		"4070284000":           "",
+	} {
+		if c := AccountCurrency(acc); c != cur {
+			t.Fatalf("AccountCurrency(%s), expected %q, got %q", acc, cur, c)
+		}
+	}
+
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if c := imp.AccSection[0].GetCurrency(); c != CURRENCY_RUB {
+		t.Fatalf("account section currency, expected %s, got %s", CURRENCY_RUB, c)
+	}
+	imp.Documents = append(imp.Documents, &PPDocument{Num: 10,
+		Sum:       100,
+		Payer:     Party{Account: "40702840000000001234"},
+		Receiver:  Party{Account: TEST_DOC0_PAYER_ACC},
+		DebetDate: time.Now(),
+		Currency:  CURRENCY_USD,
+	})
+	totals := imp.Totals(TEST_DOC0_PAYER_ACC)
+	if len(totals) != 2 {
+		t.Fatalf("totals count, expected 2, got %d", len(totals))
+	}
+	if totals[0].Currency != CURRENCY_RUB || totals[0].Count != TEST_DOC_COUNT || totals[0].Kredit != TEST_DOC0_SUM+TEST_DOC2_SUM {
+		t.Fatalf("ruble totals, got %+v", totals[0])
+	}
+	if totals[1].Currency != CURRENCY_USD || totals[1].Count != 1 || totals[1].Debet != 100 {
+		t.Fatalf("dollar totals, got %+v", totals[1])
+	}
+	if views := imp.Filter("", FilterCurrency(CURRENCY_USD)); len(views) != 1 {
+		t.Fatalf("filtered view count by currency, expected 1, got %d", len(views))
+	}
+
+	rub_doc := &PPDocument{Num: 1, Date: time.Now(), Sum: 100,
+		Payer:    Party{Name: "Плательщик", Account: "40702810000000000001"},
+		Receiver: Party{Name: "Получатель", Account: "40702810000000000002"},
+	}
+	usd_doc := &PPDocument{Num: 2, Date: time.Now(), Sum: 100,
+		Payer:    Party{Name: "Плательщик", Account: "40702840000000000001"},
+		Receiver: Party{Name: "Получатель", Account: "40702840000000000002"},
+	}
+	if _, err := NewBankExport([]BankExportDocument{rub_doc, usd_doc}).Marshal(); err == nil {
+		t.Fatal("export of documents in different currencies must fail")
+	}
+	mixed_doc := &PPDocument{Num: 3, Date: time.Now(), Sum: 100,
+		Payer:    Party{Name: "Плательщик", Account: "40702840000000000001"},
+		Receiver: Party{Name: "Получатель", Account: "40702810000000000002"},
+	}
+	if _, err := NewBankExport([]BankExportDocument{mixed_doc}).Marshal(); err != nil {
+		t.Fatalf("export of a currency sale document failed: %v", err)
+	}
+	mixed_doc.Currency = CURRENCY_RUB
+	if _, err := NewBankExport([]BankExportDocument{mixed_doc}).Marshal(); err == nil {
+		t.Fatal("export of a document with payer account in another currency must fail")
+	}
+	if _, err := NewBankExport([]BankExportDocument{usd_doc}).Marshal(); err != nil {
+		t.Fatalf("export of a dollar document failed: %v", err)
+	}
+}
+
+func TestConvertEncoding(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	dos, err := ConvertEncoding(f_cont, ENCODING_TYPE_DOS)
+	if err != nil {
+		t.Fatalf("ConvertEncoding failed: %v", err)
+	}
+	if enc, err := DetectEncoding(dos); err != nil || enc != ENCODING_TYPE_DOS {
+		t.Fatalf("DetectEncoding, expected %s, got %s, %v", ENCODING_TYPE_DOS, enc, err)
+	}
+	utf, enc, err := DecodeFile(dos)
+	if err != nil || enc != ENCODING_TYPE_DOS {
+		t.Fatalf("DecodeFile failed: %s, %v", enc, err)
+	}
+	if !strings.Contains(string(utf), "Получатель1=ИП Пупкин О.А.") {
+		t.Fatal("decoded file must contain receiver name")
+	}
+	win, err := ConvertEncoding(utf, ENCODING_TYPE_WIN)
+	if err != nil {
+		t.Fatalf("ConvertEncoding from UTF-8 failed: %v", err)
+	}
+	if !bytes.Equal(win, f_cont) {
+		t.Fatal("file converted back to Windows differs from the source file")
+	}
+	if _, err := ConvertEncoding(f_cont, ENCODING_TYPE_NOT_DEFINED); err == nil {
+		t.Fatal("conversion to undefined encoding must fail")
+	}
+
+	//encoding is taken from the file
+	for _, data := range [][]byte{dos, utf} {
+		imp := NewBankImport()
+		if err := imp.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if imp.EncodingType != ENCODING_TYPE_DOS || imp.Documents[2].GetReceiver().Name != "ИП Пупкин О.А." {
+			t.Fatalf("Unmarshal with detected encoding, got %s, %s", imp.EncodingType, imp.Documents[2].GetReceiver().Name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := &PPDocument{Num: 1,
+		Date: time.Now(),
+		Sum:  1000,
+		Payer: Party{Name: `ООО "Рога и Копыта"`,
+			Inn:     "1234567891",
+			Account: "40702810000000000001",
+			Bank:    BankInfo{Name: "Банк", Bik: "044525225", Account: "30101810400000000225"},
+		},
+		Receiver: Party{Name: `ИП Иванов А.А.`,
+			Inn:     "111122223344",
+			Account: "40802810000000000002",
+			Bank:    BankInfo{Name: "Банк", Bik: "044525225", Account: "30101810400000000225"},
+		},
+		Order:      5,
+		PayComment: "За товары",
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	exp := NewBankExport([]BankExportDocument{doc})
+	if err := exp.Validate(); err != nil {
+		t.Fatalf("export Validate failed: %v", err)
+	}
+	b, err := exp.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	//export file can be imported back
+	imp := NewBankImport()
+	if err := imp.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal of export failed: %v", err)
+	}
+	if len(imp.Documents) != 1 || imp.Documents[0].GetSum() != doc.Sum || imp.Documents[0].GetPayer().Account != doc.Payer.Account {
+		t.Fatalf("imported export documents, got %+v", imp.Documents)
+	}
+	if err := imp.Validate(); err != nil {
+		t.Fatalf("imported export Validate failed: %v", err)
+	}
+
+	doc.Receiver.Inn = "12345"
+	doc.Payer.Bank.Bik = ""
+	doc.Order = 0
+	err = doc.Validate()
+	if err == nil {
+		t.Fatal("Validate of invalid document must fail")
+	}
+	for _, s := range []string{"ПолучательИНН", "ПлательщикБИК", "Очередность"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Fatalf("Validate error must contain %s: %v", s, err)
+		}
+	}
+
+	acc := Account{Account: TEST_DOC0_PAYER_ACC, BalanceStart: 100, Debet: 50, Kredit: 30, BalanceEnd: 121}
+	if err := acc.Validate(); err == nil {
+		t.Fatal("Validate of account with wrong end balance must fail")
+	}
+}
+
+func TestPayerProfile(t *testing.T) {
+	if _, err := NewPayerProfile(Party{Name: "ООО Тест", Inn: "123"}); err == nil {
+		t.Fatal("NewPayerProfile with invalid party must fail")
+	}
+	payer := Party{Name: `ООО "Рога и Копыта"`,
+		Inn:     "1234567891",
+		Account: "40702810000000000001",
+		Bank:    BankInfo{Name: "Банк", Place: "г. Москва", Bik: "044525225", Account: "30101810400000000225"},
+	}
+	profile, err := NewPayerProfile(payer)
+	if err != nil {
+		t.Fatalf("NewPayerProfile failed: %v", err)
+	}
+	receiver := Party{Name: `ИП Иванов А.А.`,
+		Inn:     "111122223344",
+		Account: "40802810000000000002",
+		Bank:    BankInfo{Name: "Банк", Bik: "044525225", Account: "30101810400000000225"},
+	}
+	doc1 := &PPDocument{Num: 1, Date: time.Now(), Sum: 100, Receiver: receiver, Order: 5, PayComment: "За товары"}
+	//other account of the company at another bank
+	doc2 := &PPDocument{Num: 2, Date: time.Now(), Sum: 200, Receiver: receiver, Order: 5, PayComment: "За услуги",
+		Payer: Party{Account: "40702810900000000003", Bank: BankInfo{Name: "Другой банк", Bik: "044525593", Account: "30101810200000000593"}},
+	}
+	exp := NewBankExport([]BankExportDocument{doc1, doc2})
+	exp.Payer = profile
+	//documents are not changed by Validate
+	if err := exp.Validate(); err == nil || doc1.Payer.Inn != "" {
+		t.Fatalf("Validate before Prepare must fail without changing documents: %v, %+v", err, doc1.Payer)
+	}
+	if err := exp.Prepare(); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if err := exp.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if !reflect.DeepEqual(doc1.Payer, payer) {
+		t.Fatalf("payer from profile, expected %+v, got %+v", payer, doc1.Payer)
+	}
+	if doc2.Payer.Inn != payer.Inn || doc2.Payer.Name != payer.Name || doc2.Payer.Bank.Bik != "044525593" || doc2.Payer.Bank.Place != "" {
+		t.Fatalf("payer with other account, got %+v", doc2.Payer)
+	}
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if d := profile.NewPPDocument(); !reflect.DeepEqual(d.Payer, payer) {
+		t.Fatalf("NewPPDocument payer, got %+v", d.Payer)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dronm/clbnk"
+)
+
+// readJSONFile unmarshals JSON file to v.
+func readJSONFile(fileName string, v interface{}) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", fileName, err)
+	}
+	return nil
+}
+
+// build creates a payment file from CSV or JSON payment register.
+func build(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("build", stderr)
+	map_file := fs.String("map", "", "JSON file with column to document field mapping")
+	tmpl_file := fs.String("template", "", "JSON file with document default values")
+	payer_file := fs.String("payer", "", "JSON file with payer profile for empty payer fields")
+	bik_file := fs.String("bik", "", "Bank of Russia ED807 BIK directory file for empty bank values")
+	check_vat := fs.Bool("vat", false, "check VAT statements of payment purposes")
+	format := fs.String("format", "", "register format: csv or json, by file extension by default")
+	comma := fs.String("comma", ";", "CSV field delimiter")
+	enc := fs.String("encoding", ENC_WIN, "output encoding: win or dos")
+	out := fs.String("o", "", "output file, standard output by default")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+
+	exp := clbnk.NewBankExport(nil)
+	exp.CheckVat = *check_vat
+	switch *enc {
+	case ENC_WIN:
+		exp.EncodingType = clbnk.ENCODING_TYPE_WIN
+	case ENC_DOS:
+		exp.EncodingType = clbnk.ENCODING_TYPE_DOS
+	default:
+		fmt.Fprintf(stderr, "unknown encoding %s\n", *enc)
+		return EXIT_USAGE
+	}
+	if *format == "" {
+		*format = FORMAT_CSV
+		if strings.EqualFold(filepath.Ext(fs.Arg(0)), ".json") {
+			*format = FORMAT_JSON
+		}
+	}
+	comma_r := []rune(*comma)
+	if len(comma_r) != 1 {
+		fmt.Fprintf(stderr, "invalid delimiter %s\n", *comma)
+		return EXIT_USAGE
+	}
+
+	reg := clbnk.NewPaymentRegister(nil)
+	reg.Comma = comma_r[0]
+	if *map_file != "" {
+		if err := readJSONFile(*map_file, &reg.Mapping); err != nil {
+			fmt.Fprintln(stderr, err)
+			return EXIT_USAGE
+		}
+	}
+	if *tmpl_file != "" {
+		reg.Template = &clbnk.PPDocument{}
+		if err := readJSONFile(*tmpl_file, reg.Template); err != nil {
+			fmt.Fprintln(stderr, err)
+			return EXIT_USAGE
+		}
+	}
+
+	if *payer_file != "" {
+		var payer clbnk.Party
+		if err := readJSONFile(*payer_file, &payer); err != nil {
+			fmt.Fprintln(stderr, err)
+			return EXIT_USAGE
+		}
+		profile, err := clbnk.NewPayerProfile(payer)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s:\n%v\n", *payer_file, err)
+			return EXIT_USAGE
+		}
+		reg.Payer = profile
+	}
+
+	if *bik_file != "" {
+		dir, err := clbnk.LoadED807File(*bik_file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return EXIT_USAGE
+		}
+		exp.BankDirectory = dir
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+	defer f.Close()
+	var docs []*clbnk.PPDocument
+	switch *format {
+	case FORMAT_CSV:
+		docs, err = reg.ReadCSV(f)
+	case FORMAT_JSON:
+		docs, err = reg.ReadJSON(f)
+	default:
+		fmt.Fprintf(stderr, "unknown format %s\n", *format)
+		return EXIT_USAGE
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s:\n%v\n", fs.Arg(0), err)
+		return EXIT_FAILED
+	}
+	if len(docs) == 0 {
+		fmt.Fprintf(stderr, "%s: no payments\n", fs.Arg(0))
+		return EXIT_FAILED
+	}
+
+	for _, d := range docs {
+		exp.Documents = append(exp.Documents, d)
+	}
+	if err := checkRows(exp, reg.Rows); err != nil {
+		fmt.Fprintf(stderr, "%s:\n%v\n", fs.Arg(0), err)
+		return EXIT_FAILED
+	}
+	data, err := exp.Marshal()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_FAILED
+	}
+	if *out == "" {
+		_, err = stdout.Write(data)
+	} else {
+		err = os.WriteFile(*out, data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+	return EXIT_OK
+}
+
+// checkRows prepares and validates documents of the export one by one,
+// then checks numbers and currencies between documents.
+// Errors are returned with register rows of documents.
+func checkRows(exp *clbnk.BankExport, rows []int) error {
+	var reg_err clbnk.RegisterError
+	nums := make(map[string]int)
+	currency, currency_row := "", 0
+	for i, doc := range exp.Documents {
+		row := rows[i]
+		one := *exp
+		one.Documents = []clbnk.BankExportDocument{doc}
+		err := one.Prepare()
+		if err == nil {
+			err = one.Validate()
+		}
+		if err != nil {
+			reg_err = append(reg_err, &clbnk.RowError{Row: row, Err: err})
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%d/%d", doc.GetPayer().Account, doc.GetDate().Year(), doc.GetNum())
+		if prev, ok := nums[key]; ok && doc.GetNum() != 0 {
+			reg_err = append(reg_err, &clbnk.RowError{Row: row, Err: fmt.Errorf("number %d of account %s is used in row %d", doc.GetNum(), doc.GetPayer().Account, prev)})
+		}
+		nums[key] = row
+		if cur := doc.GetCurrency(); currency == "" {
+			currency, currency_row = cur, row
+		} else if cur != "" && cur != currency {
+			reg_err = append(reg_err, &clbnk.RowError{Row: row, Err: fmt.Errorf("currency %s differs from %s of row %d", cur, currency, currency_row)})
+		}
+	}
+	if len(reg_err) > 0 {
+		return reg_err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/dronm/clbnk"
+)
+
+// documentKey identifies a document in both statements.
+type documentKey struct {
+	docType         clbnk.DocumentType
+	num             int
+	date            string
+	payerAccount    string
+	receiverAccount string
+}
+
+func newDocumentKey(d clbnk.Document) documentKey {
+	return documentKey{docType: d.GetType(),
+		num:             d.GetNum(),
+		date:            formatDate(d.GetDate()),
+		payerAccount:    d.GetPayer().Account,
+		receiverAccount: d.GetReceiver().Account,
+	}
+}
+
+func documentLine(d clbnk.Document) string {
+	return fmt.Sprintf("%s №%d от %s %.2f %s %s -> %s: %s", d.GetType(), d.GetNum(), formatDate(d.GetDate()),
+		d.GetSum(), d.GetCurrency(), d.GetPayer().Account, d.GetReceiver().Account, d.GetPurpose(),
+	)
+}
+
+func amountDiffers(a, b float64) bool {
+	return math.Abs(a-b) > clbnk.BALANCE_EPS
+}
+
+// diff prints differences of two statements: account sections
+// and documents only in the first (-) or the second (+) file,
+// documents with changed sum or purpose (~).
+func diff(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+	imp1, err := loadImport(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+	imp2, err := loadImport(args[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+	n := diffAccounts(imp1.AccSection, imp2.AccSection, stdout)
+	n += diffDocuments(imp1.Documents, imp2.Documents, stdout)
+	if n > 0 {
+		return EXIT_FAILED
+	}
+	return EXIT_OK
+}
+
+// diffAccounts prints account section differences and returns their count.
+func diffAccounts(acc1, acc2 []clbnk.Account, w io.Writer) int {
+	n := 0
+	accounts := make(map[string]*clbnk.Account)
+	for i := range acc2 {
+		accounts[acc2[i].Account] = &acc2[i]
+	}
+	for i := range acc1 {
+		a1 := &acc1[i]
+		a2, ok := accounts[a1.Account]
+		if !ok {
+			fmt.Fprintf(w, "- account %s\n", a1.Account)
+			n++
+			continue
+		}
+		delete(accounts, a1.Account)
+		for _, v := range []struct {
+			name   string
+			v1, v2 float64
+		}{{"НачальныйОстаток", a1.BalanceStart, a2.BalanceStart},
+			{"ВсегоПоступило", a1.Debet, a2.Debet},
+			{"ВсегоСписано", a1.Kredit, a2.Kredit},
+			{"КонечныйОстаток", a1.BalanceEnd, a2.BalanceEnd},
+		} {
+			if amountDiffers(v.v1, v.v2) {
+				fmt.Fprintf(w, "~ account %s %s: %.2f -> %.2f\n", a1.Account, v.name, v.v1, v.v2)
+				n++
+			}
+		}
+	}
+	for i := range acc2 {
+		if _, ok := accounts[acc2[i].Account]; ok {
+			fmt.Fprintf(w, "+ account %s\n", acc2[i].Account)
+			n++
+		}
+	}
+	return n
+}
+
+// diffDocuments prints document differences and returns their count.
+func diffDocuments(docs1, docs2 []clbnk.BankImportDocument, w io.Writer) int {
+	n := 0
+	docs := make(map[documentKey][]clbnk.BankImportDocument)
+	for _, d := range docs2 {
+		k := newDocumentKey(d)
+		docs[k] = append(docs[k], d)
+	}
+	for _, d1 := range docs1 {
+		k := newDocumentKey(d1)
+		if len(docs[k]) == 0 {
+			fmt.Fprintf(w, "- %s\n", documentLine(d1))
+			n++
+			continue
+		}
+		d2 := docs[k][0]
+		docs[k] = docs[k][1:]
+		if amountDiffers(d1.GetSum(), d2.GetSum()) || d1.GetPurpose() != d2.GetPurpose() {
+			fmt.Fprintf(w, "~ %s\n  %s\n", documentLine(d1), documentLine(d2))
+			n++
+		}
+	}
+	for _, d2 := range docs2 {
+		k := newDocumentKey(d2)
+		for _, d := range docs[k] {
+			if d == d2 {
+				fmt.Fprintf(w, "+ %s\n", documentLine(d2))
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,276 @@
+// Command clbnk inspects, validates, converts and compares
+// 1CClientBankExchange files.
+//
+// Usage:
+//
+//	clbnk inspect [-account ACC] FILE
+//	clbnk validate FILE...
+//	clbnk convert [-encoding win|dos|utf8] [-format 1c|json|csv] [-o OUT] FILE
+//	clbnk diff FILE1 FILE2
+//	clbnk build [-map MAP.json] [-template DOC.json] [-payer PAYER.json] [-bik ED807.xml] [-vat] [-format csv|json] [-comma ;] [-encoding win|dos] [-o OUT] FILE
+//
+// Files may be in Windows (CP1251), DOS (CP866) or UTF-8 encoding.
+// Кодировка value of a file converted to UTF-8 is kept as is,
+// because the exchange format has Windows and DOS values only.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dronm/clbnk"
+)
+
+// Exit codes.
+const (
+	EXIT_OK     = 0
+	EXIT_FAILED = 1 // invalid file, files differ
+	EXIT_USAGE  = 2 // wrong arguments, read errors
+)
+
+// Command line values.
+const (
+	ENC_WIN  = "win"
+	ENC_DOS  = "dos"
+	ENC_UTF8 = "utf8"
+
+	FORMAT_1C   = "1c"
+	FORMAT_JSON = "json"
+	FORMAT_CSV  = "csv"
+
+	DATE_FORMAT = "02.01.2006"
+)
+
+const usage = `usage: clbnk <command> [arguments]
+
+commands:
+  inspect [-account ACC] FILE    print header, accounts, balances and documents
+  validate FILE...               check files, exit code 1 if any file is invalid
+  convert [-encoding win|dos|utf8] [-format 1c|json|csv] [-o OUT] FILE
+                                 change encoding or output format,
+                                 Кодировка value is kept on utf8 conversion
+  diff FILE1 FILE2               compare statements, exit code 1 if they differ
+  build [-map MAP.json] [-template DOC.json] [-payer PAYER.json] [-bik ED807.xml]
+        [-vat] [-format csv|json] [-comma ;] [-encoding win|dos] [-o OUT] FILE
+                                 create payment file from payment register,
+                                 exit code 1 if any row is invalid
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+	switch args[0] {
+	case "inspect":
+		return inspect(args[1:], stdout, stderr)
+	case "validate":
+		return validate(args[1:], stdout, stderr)
+	case "convert":
+		return convert(args[1:], stdout, stderr)
+	case "diff":
+		return diff(args[1:], stdout, stderr)
+	case "build":
+		return build(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return EXIT_OK
+	}
+	fmt.Fprintf(stderr, "unknown command %s\n%s", args[0], usage)
+	return EXIT_USAGE
+}
+
+// newFlagSet returns a flag set printing errors to stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// loadImport reads exchange file in any supported encoding.
+// EncodingType is set to the value given in the file.
+func loadImport(fileName string) (*clbnk.BankImport, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	imp := clbnk.NewBankImport()
+	if err := imp.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return imp, nil
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(DATE_FORMAT)
+}
+
+func inspect(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("inspect", stderr)
+	account := fs.String("account", "", "account for document directions, statement account by default")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+	imp, err := loadImport(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s\n", imp.Version)
+	fmt.Fprintf(w, "Encoding:\t%s\n", imp.EncodingType)
+	fmt.Fprintf(w, "Sender:\t%s\n", imp.Sender)
+	fmt.Fprintf(w, "Created:\t%s %s\n", formatDate(imp.CreateDate), imp.CreateTime)
+	fmt.Fprintf(w, "Period:\t%s - %s\n", formatDate(imp.DateFrom), formatDate(imp.DateTo))
+	fmt.Fprintf(w, "Account:\t%s\n", imp.Account)
+	w.Flush()
+
+	if len(imp.AccSection) > 0 {
+		fmt.Fprintln(stdout, "\nAccounts:")
+		w = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Account\tCurrency\tFrom\tTo\tStart\tIncoming\tOutgoing\tEnd\t")
+		for _, acc := range imp.AccSection {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%.2f\t\n", acc.Account, acc.GetCurrency(),
+				formatDate(acc.DateFrom), formatDate(acc.DateTo),
+				acc.BalanceStart, acc.Debet, acc.Kredit, acc.BalanceEnd,
+			)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintf(stdout, "\nDocuments: %d\n", len(imp.Documents))
+	if len(imp.Documents) == 0 {
+		return EXIT_OK
+	}
+	w = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Currency\tCount\tIncoming\tOutgoing\t")
+	for _, t := range imp.Totals(*account) {
+		fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f\t\n", t.Currency, t.Count, t.Debet, t.Kredit)
+	}
+	w.Flush()
+
+	fmt.Fprintln(stdout)
+	w = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Type\tNum\tDate\tSum\tCurrency\tDirection\tCounterparty\tINN\tPurpose")
+	for _, v := range imp.Views(*account) {
+		c := v.Counterparty()
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%s\t%s\t%s\t%s\t%s\n", v.Type, v.Num, formatDate(v.Date),
+			v.Sum, v.Currency, v.Direction, c.Name, c.Inn, v.Purpose,
+		)
+	}
+	w.Flush()
+	return EXIT_OK
+}
+
+func validate(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+	res := EXIT_OK
+	for _, file_name := range args {
+		imp, err := loadImport(file_name)
+		if err == nil {
+			if err = imp.Validate(); err != nil {
+				err = fmt.Errorf("%s:\n%v", file_name, err)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			res = EXIT_FAILED
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: OK\n", file_name)
+	}
+	return res
+}
+
+func convert(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("convert", stderr)
+	enc := fs.String("encoding", "", "output encoding of 1c format: win, dos or utf8 (Кодировка value is kept)")
+	format := fs.String("format", FORMAT_1C, "output format: 1c, json or csv")
+	out := fs.String("o", "", "output file, standard output by default")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return EXIT_USAGE
+	}
+	if *format != FORMAT_1C && *enc != "" && *enc != ENC_UTF8 {
+		fmt.Fprintf(stderr, "%s format is always in UTF-8\n", *format)
+		return EXIT_USAGE
+	}
+
+	var data []byte
+	var err error
+	switch *format {
+	case FORMAT_1C:
+		data, err = convertEncoding(fs.Arg(0), *enc)
+
+	case FORMAT_JSON:
+		var imp *clbnk.BankImport
+		if imp, err = loadImport(fs.Arg(0)); err == nil {
+			data, err = json.MarshalIndent(imp, "", "  ")
+			data = append(data, '\n')
+		}
+
+	case FORMAT_CSV:
+		var imp *clbnk.BankImport
+		if imp, err = loadImport(fs.Arg(0)); err == nil {
+			var buf bytes.Buffer
+			err = clbnk.NewStatementTable().WriteCSV(&buf, imp)
+			data = buf.Bytes()
+		}
+
+	default:
+		fmt.Fprintf(stderr, "unknown format %s\n", *format)
+		return EXIT_USAGE
+	}
+	if err == nil {
+		if *out == "" {
+			_, err = stdout.Write(data)
+		} else {
+			err = os.WriteFile(*out, data, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_USAGE
+	}
+	return EXIT_OK
+}
+
+// convertEncoding returns exchange file in the encoding,
+// empty encoding means the encoding of the file.
+func convertEncoding(fileName, enc string) ([]byte, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	switch enc {
+	case ENC_WIN:
+		return clbnk.ConvertEncoding(data, clbnk.ENCODING_TYPE_WIN)
+	case ENC_DOS:
+		return clbnk.ConvertEncoding(data, clbnk.ENCODING_TYPE_DOS)
+	case ENC_UTF8:
+		//Кодировка value is Windows or DOS only and is kept as is
+		data, _, err = clbnk.DecodeFile(data)
+		return data, err
+	case "":
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %s", enc)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const TEST_STATEMENT = "../../kl_to_1c.txt"
+
+func runTest(t *testing.T, args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String() + stderr.String()
+}
+
+func TestInspect(t *testing.T) {
+	code, out := runTest(t, "inspect", TEST_STATEMENT)
+	if code != EXIT_OK {
+		t.Fatalf("inspect exit code %d: %s", code, out)
+	}
+	for _, s := range []string{"40702810000000074935", "252842.49", "79245.05", "Documents: 3", "Банковский ордер"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("inspect output must contain %s:\n%s", s, out)
+		}
+	}
+	if code, _ := runTest(t, "inspect"); code != EXIT_USAGE {
+		t.Fatalf("inspect without file, expected exit code %d, got %d", EXIT_USAGE, code)
+	}
+}
+
+func TestConvertDiff(t *testing.T) {
+	dir := t.TempDir()
+	dos_file := filepath.Join(dir, "dos.txt")
+	if code, out := runTest(t, "convert", "-encoding", ENC_DOS, "-o", dos_file, TEST_STATEMENT); code != EXIT_OK {
+		t.Fatalf("convert exit code %d: %s", code, out)
+	}
+	if _, out := runTest(t, "inspect", dos_file); !strings.Contains(out, "DOS") {
+		t.Fatalf("converted file encoding must be DOS:\n%s", out)
+	}
+	//Кодировка value is kept
+	code, out := runTest(t, "convert", "-encoding", ENC_UTF8, dos_file)
+	if code != EXIT_OK || !strings.Contains(out, "Кодировка=DOS") || !strings.Contains(out, "КонецФайла") {
+		t.Fatalf("convert to UTF-8 exit code %d: %s", code, out)
+	}
+	if code, out := runTest(t, "diff", TEST_STATEMENT, dos_file); code != EXIT_OK || out != "" {
+		t.Fatalf("diff of the same statements, exit code %d: %s", code, out)
+	}
+
+	utf_file := filepath.Join(dir, "utf8.txt")
+	if err := os.WriteFile(utf_file, []byte(strings.Replace(out, "Сумма=150000.00", "Сумма=150001.00", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, out = runTest(t, "diff", TEST_STATEMENT, utf_file)
+	if code != EXIT_FAILED || !strings.Contains(out, "150001.00") {
+		t.Fatalf("diff of different statements, exit code %d: %s", code, out)
+	}
+
+	if code, out := runTest(t, "convert", "-format", FORMAT_JSON, TEST_STATEMENT); code != EXIT_OK || !strings.Contains(out, `"account": "40702810000000074935"`) {
+		t.Fatalf("convert to JSON exit code %d: %s", code, out)
+	}
+	if code, _ := runTest(t, "convert", "-format", FORMAT_CSV, "-encoding", ENC_WIN, TEST_STATEMENT); code != EXIT_USAGE {
+		t.Fatalf("convert to CSV with encoding, expected exit code %d, got %d", EXIT_USAGE, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	//test statement has 13 digit INN
+	code, out := runTest(t, "validate", TEST_STATEMENT)
+	if code != EXIT_FAILED || !strings.Contains(out, "ПолучательИНН") {
+		t.Fatalf("validate exit code %d: %s", code, out)
+	}
+	if code, _ := runTest(t, "validate", "no_such_file.txt"); code != EXIT_FAILED {
+		t.Fatalf("validate of absent file, expected exit code %d, got %d", EXIT_FAILED, code)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	tmpl_file := filepath.Join(dir, "template.json")
+	tmpl := `{"payType": "Электронно", "order": 5}`
+	payer_file := filepath.Join(dir, "payer.json")
+	payer := `{"name": "ООО \"Рога и Копыта\"", "inn": "1234567891", "account": "40702810000000000001",
+		"bank": {"name": "Банк", "bik": "044525225", "account": "30101810400000000225"}}`
+	map_file := filepath.Join(dir, "map.json")
+	mapping := `{"Номер": "num", "Дата": "date", "Сумма": "sum", "Получатель": "receiver.name",
+		"ИНН": "receiver.inn", "Счет": "receiver.account", "БИК": "receiver.bank.bik", "Назначение": "payComment"}`
+	reg_file := filepath.Join(dir, "register.csv")
+	reg := "Номер;Дата;Сумма;Получатель;ИНН;Счет;БИК;Назначение\n" +
+		"1;09.01.2024;1500,50;ИП Иванов А.А.;111122223344;40802810000000000002;044525225;За товары\n"
+	for f, cont := range map[string]string{tmpl_file: tmpl, payer_file: payer, map_file: mapping, reg_file: reg} {
+		if err := os.WriteFile(f, []byte(cont), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out_file := filepath.Join(dir, "to_bank.txt")
+	if code, out := runTest(t, "build", "-map", map_file, "-template", tmpl_file, "-payer", payer_file, "-o", out_file, reg_file); code != EXIT_OK {
+		t.Fatalf("build exit code %d: %s", code, out)
+	}
+	if code, out := runTest(t, "validate", out_file); code != EXIT_OK {
+		t.Fatalf("validate of built file exit code %d: %s", code, out)
+	}
+	if _, out := runTest(t, "inspect", out_file); !strings.Contains(out, "1500.50") {
+		t.Fatalf("built file must contain the payment:\n%s", out)
+	}
+
+	//receiver bank from directory
+	if code, out := runTest(t, "build", "-map", map_file, "-template", tmpl_file, "-payer", payer_file, "-bik", "../../ed807.xml", "-o", out_file, reg_file); code != EXIT_OK {
+		t.Fatalf("build with BIK directory exit code %d: %s", code, out)
+	}
+	if _, out := runTest(t, "convert", "-encoding", ENC_UTF8, out_file); !strings.Contains(out, "ПолучательБанк1=ПАО Сбербанк") {
+		t.Fatalf("built file must contain receiver bank name:\n%s", out)
+	}
+
+	if code, out := runTest(t, "build", "-map", map_file, "-template", tmpl_file, "-payer", payer_file, "-vat", "-o", out_file, reg_file); code != EXIT_FAILED || !strings.Contains(out, "row 2: ") || !strings.Contains(out, "VAT") {
+		t.Fatalf("build with VAT check of purpose without VAT, exit code %d: %s", code, out)
+	}
+
+	//errors of the whole export are reported by register rows
+	dupl := reg + "1;09.01.2024;100;ИП Иванов А.А.;111122223344;40802810000000000002;044525999;За товары\n" +
+		"1;09.01.2024;200;ИП Иванов А.А.;111122223344;40802810000000000002;044525225;За товары\n"
+	if err := os.WriteFile(reg_file, []byte(dupl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, out := runTest(t, "build", "-map", map_file, "-template", tmpl_file, "-payer", payer_file, "-bik", "../../ed807.xml", "-o", out_file, reg_file)
+	if code != EXIT_FAILED || !strings.Contains(out, "row 3: ") || !strings.Contains(out, "044525999") ||
+		!strings.Contains(out, "row 4: number 1 of account 40702810000000000001 is used in row 2") {
+		t.Fatalf("build of register with unknown BIK and duplicate numbers exit code %d: %s", code, out)
+	}
+
+	bad := reg + "2;10.01.2024;-1;ИП Иванов А.А.;111122223344;40802810000000000002;044525225;За товары\n"
+	if err := os.WriteFile(reg_file, []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, out = runTest(t, "build", "-map", map_file, "-template", tmpl_file, "-payer", payer_file, "-o", out_file, reg_file)
+	if code != EXIT_FAILED || !strings.Contains(out, "row 3") {
+		t.Fatalf("build of invalid register exit code %d: %s", code, out)
+	}
+}
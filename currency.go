@@ -0,0 +1,134 @@
+package clbnk
+
+import "fmt"
+
+// ISO 4217 alphabetic currency codes.
+const (
+	CURRENCY_RUB = "RUB"
+	CURRENCY_USD = "USD"
+	CURRENCY_EUR = "EUR"
+	CURRENCY_CNY = "CNY"
+	CURRENCY_GBP = "GBP"
+	CURRENCY_CHF = "CHF"
+	CURRENCY_JPY = "JPY"
+	CURRENCY_KZT = "KZT"
+	CURRENCY_BYN = "BYN"
+	CURRENCY_AED = "AED"
+	CURRENCY_TRY = "TRY"
+	CURRENCY_INR = "INR"
+	CURRENCY_HKD = "HKD"
+)
+
+// accountCurrencies maps currency codes of account numbers (digits 6-8)
+// to ISO 4217 alphabetic codes. 810 is the ruble code used in accounts.
+var accountCurrencies = map[string]string{"810": CURRENCY_RUB,
+	"643": CURRENCY_RUB,
+	"840": CURRENCY_USD,
+	"978": CURRENCY_EUR,
+	"156": CURRENCY_CNY,
+	"826": CURRENCY_GBP,
+	"756": CURRENCY_CHF,
+	"392": CURRENCY_JPY,
+	"398": CURRENCY_KZT,
+	"933": CURRENCY_BYN,
+	"784": CURRENCY_AED,
+	"949": CURRENCY_TRY,
+	"356": CURRENCY_INR,
+	"344": CURRENCY_HKD,
+}
+
+// AccountCurrency returns ISO 4217 currency code by the account number,
+// empty string if the account is not a 20 digit account or the code is unknown.
+func AccountCurrency(account string) string {
+	if len(account) != 20 {
+		return ""
+	}
+	return accountCurrencies[account[5:8]]
+}
+
+// documentCurrency returns the currency if given, otherwise the currency
+// of the payer account or of the receiver account.
+func documentCurrency(currency, payerAccount, receiverAccount string) string {
+	if currency != "" {
+		return currency
+	}
+	if c := AccountCurrency(payerAccount); c != "" {
+		return c
+	}
+	return AccountCurrency(receiverAccount)
+}
+
+// checkDocumentCurrency returns an error if the currency of the document differs
+// from the currency of its payer account. The receiver account may be
+// in another currency: conversion between own ruble and currency accounts.
+func checkDocumentCurrency(doc Document) error {
+	cur := doc.GetCurrency()
+	acc := doc.GetPayer().Account
+	if c := AccountCurrency(acc); c != "" && c != cur {
+		return fmt.Errorf("document %d: payer account %s currency %s differs from %s", doc.GetNum(), acc, c, cur)
+	}
+	return nil
+}
+
+// checkExportCurrency returns an error if documents are in different currencies
+// or the payer account of a document differs from its currency.
+func checkExportCurrency(documents []BankExportDocument) error {
+	currency := ""
+	for _, d := range documents {
+		if err := checkDocumentCurrency(d); err != nil {
+			return err
+		}
+		if cur := d.GetCurrency(); currency == "" {
+			currency = cur
+		} else if cur != "" && cur != currency {
+			return fmt.Errorf("document %d: currency %s differs from %s, documents in different currencies can not be exported together", d.GetNum(), cur, currency)
+		}
+	}
+	return nil
+}
+
+// CurrencyTotals contains document totals of one currency.
+type CurrencyTotals struct {
+	Currency string  `json:"currency"`
+	Count    int     `json:"count"`
+	Debet    float64 `json:"debet"`  // incoming
+	Kredit   float64 `json:"kredit"` // outgoing
+}
+
+// Totals returns incoming and outgoing totals of the documents by currency,
+// in order of the first document with the currency.
+// Direction is determined relative to the account, empty account means
+// the account of the statement. Documents with undefined direction are only counted.
+func (e *BankImport) Totals(account string) []CurrencyTotals {
+	if account == "" {
+		account = e.Account
+	}
+	res := make([]CurrencyTotals, 0)
+	ind := make(map[string]int)
+	for _, doc := range e.Documents {
+		cur := doc.GetCurrency()
+		i, ok := ind[cur]
+		if !ok {
+			i = len(res)
+			ind[cur] = i
+			res = append(res, CurrencyTotals{Currency: cur})
+		}
+		res[i].Count++
+		switch doc.Direction(account) {
+		case DIRECTION_INCOMING:
+			res[i].Debet += doc.GetSum()
+		case DIRECTION_OUTGOING:
+			res[i].Kredit += doc.GetSum()
+		}
+	}
+	return res
+}
+
+// GetCurrency returns the currency of the account section,
+// derived from the account number if not set.
+func (a *Account) GetCurrency() string {
+	if a.Currency != "" {
+		return a.Currency
+	}
+	return AccountCurrency(a.Account)
+}
@@ -0,0 +1,75 @@
+package clbnk
+
+import "time"
+
+// Direction is a money movement direction of a document relative to an account.
+type Direction int
+
+const (
+	DIRECTION_NOT_DEFINED Direction = iota
+	DIRECTION_INCOMING
+	DIRECTION_OUTGOING
+)
+
+// documentDirection determines document direction.
+// Account numbers are checked first: payer account means outgoing money,
+// receiver account means incoming money. If the account is empty or
+// both accounts match it, ДатаСписано/ДатаПоступило values are used.
+func documentDirection(account, payerAccount, receiverAccount string, kreditDate, debetDate time.Time) Direction {
+	is_payer := account != "" && payerAccount == account
+	is_receiver := account != "" && receiverAccount == account
+	if is_payer && !is_receiver {
+		return DIRECTION_OUTGOING
+
+	} else if is_receiver && !is_payer {
+		return DIRECTION_INCOMING
+
+	} else if account != "" && !is_payer && !is_receiver {
+		//document does not belong to the account
+		return DIRECTION_NOT_DEFINED
+	}
+
+	if !debetDate.IsZero() && kreditDate.IsZero() {
+		return DIRECTION_INCOMING
+
+	} else if !kreditDate.IsZero() && debetDate.IsZero() {
+		return DIRECTION_OUTGOING
+	}
+	return DIRECTION_NOT_DEFINED
+}
+
+// Incoming returns all documents crediting the account.
+// If account is empty, the statement account (РасчСчет) is used.
+func (e *BankImport) Incoming(account string) []BankImportDocument {
+	return e.documentsByDirection(account, DIRECTION_INCOMING)
+}
+
+// Outgoing returns all documents debiting the account.
+// If account is empty, the statement account (РасчСчет) is used.
+func (e *BankImport) Outgoing(account string) []BankImportDocument {
+	return e.documentsByDirection(account, DIRECTION_OUTGOING)
+}
+
+func (e *BankImport) documentsByDirection(account string, direction Direction) []BankImportDocument {
+	if account == "" {
+		account = e.Account
+	}
+	docs := make([]BankImportDocument, 0)
+	for _, doc := range e.Documents {
+		if doc.Direction(account) == direction {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// String returns direction name.
+func (d Direction) String() string {
+	switch d {
+	case DIRECTION_INCOMING:
+		return "Поступление"
+	case DIRECTION_OUTGOING:
+		return "Списание"
+	}
+	return ""
+}
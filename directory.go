@@ -0,0 +1,223 @@
+package clbnk
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Bank of Russia ED807 BIK directory values.
+const (
+	ED807_STATUS_DELETED      = "PSDL" // participant deleted
+	ED807_RSTR_LICENSE_REVOKE = "LWRS" // license revoked
+	ED807_ACC_CORRESPONDENT   = "CRSA"
+	ED807_ACC_DELETED         = "ACDL"
+)
+
+// DirectoryBank is a bank of BIK directory.
+type DirectoryBank struct {
+	BankInfo
+	Closed bool // bank is deleted or has its license revoked
+}
+
+// BankDirectory finds banks by BIK.
+type BankDirectory interface {
+	Lookup(bik string) (DirectoryBank, bool)
+}
+
+// BankDirectoryMap is an in-memory BIK directory.
+type BankDirectoryMap map[string]DirectoryBank
+
+// Lookup implements BankDirectory.
+func (m BankDirectoryMap) Lookup(bik string) (DirectoryBank, bool) {
+	b, ok := m[bik]
+	return b, ok
+}
+
+type ed807Document struct {
+	XMLName xml.Name     `xml:"ED807"`
+	Entries []ed807Entry `xml:"BICDirectoryEntry"`
+}
+
+type ed807Entry struct {
+	BIC  string `xml:"BIC,attr"`
+	Info struct {
+		NameP             string `xml:"NameP,attr"`
+		Tnp               string `xml:"Tnp,attr"` // settlement type: г, п, с
+		Nnp               string `xml:"Nnp,attr"` // settlement name
+		ParticipantStatus string `xml:"ParticipantStatus,attr"`
+		RstrList          []struct {
+			Rstr string `xml:"Rstr,attr"`
+		} `xml:"RstrList"`
+	} `xml:"ParticipantInfo"`
+	Accounts []struct {
+		Account               string `xml:"Account,attr"`
+		RegulationAccountType string `xml:"RegulationAccountType,attr"`
+		AccountStatus         string `xml:"AccountStatus,attr"`
+		DateOut               string `xml:"DateOut,attr"`
+	} `xml:"Accounts"`
+}
+
+func (e *ed807Entry) bank() DirectoryBank {
+	b := DirectoryBank{BankInfo: BankInfo{Bik: e.BIC, Name: e.Info.NameP}}
+	if e.Info.Nnp != "" {
+		b.Place = e.Info.Nnp
+		if e.Info.Tnp != "" {
+			b.Place = e.Info.Tnp + ". " + e.Info.Nnp
+		}
+	}
+	for _, acc := range e.Accounts {
+		if acc.RegulationAccountType == ED807_ACC_CORRESPONDENT && acc.AccountStatus != ED807_ACC_DELETED && acc.DateOut == "" {
+			b.Account = acc.Account
+			break
+		}
+	}
+	b.Closed = e.Info.ParticipantStatus == ED807_STATUS_DELETED
+	for _, r := range e.Info.RstrList {
+		if r.Rstr == ED807_RSTR_LICENSE_REVOKE {
+			b.Closed = true
+		}
+	}
+	return b
+}
+
+// ed807CharsetReader decodes Windows-1251 directory files.
+func ed807CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("ED807: unsupported charset %s", charset)
+}
+
+// LoadED807 reads the Bank of Russia ED807 BIK directory XML.
+// Entries without BIC are skipped.
+func LoadED807(r io.Reader) (BankDirectoryMap, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = ed807CharsetReader
+	var doc ed807Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("ED807: %v", err)
+	}
+	dir := make(BankDirectoryMap, len(doc.Entries))
+	for i := range doc.Entries {
+		if doc.Entries[i].BIC == "" {
+			continue
+		}
+		dir[doc.Entries[i].BIC] = doc.Entries[i].bank()
+	}
+	return dir, nil
+}
+
+// LoadED807File reads the Bank of Russia ED807 BIK directory XML file.
+func LoadED807File(fileName string) (BankDirectoryMap, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadED807(f)
+}
+
+// lookupBank returns the directory bank by BIK.
+// Error is returned if the BIK is unknown or the bank is closed.
+// Field names in errors are prefixed with the prefix.
+func (b *BankInfo) lookupBank(dir BankDirectory, prefix string) (DirectoryBank, error) {
+	dir_b, ok := dir.Lookup(b.Bik)
+	if !ok {
+		return DirectoryBank{}, fmt.Errorf("%sБИК: bank %s not found in directory", prefix, b.Bik)
+	}
+	if dir_b.Closed {
+		return DirectoryBank{}, fmt.Errorf("%sБИК: bank %s %s is closed", prefix, b.Bik, dir_b.Name)
+	}
+	return dir_b, nil
+}
+
+// CheckBank returns an error if the BIK is unknown or the bank is closed.
+// Empty BIK is not checked.
+func (b *BankInfo) CheckBank(dir BankDirectory, prefix string) error {
+	if b.Bik == "" {
+		return nil
+	}
+	_, err := b.lookupBank(dir, prefix)
+	return err
+}
+
+// FillBank fills empty bank values from the directory.
+// Error is returned if the BIK is unknown or the bank is closed.
+// Field names in errors are prefixed with the prefix.
+func (b *BankInfo) FillBank(dir BankDirectory, prefix string) error {
+	if b.Bik == "" {
+		return nil
+	}
+	dir_b, err := b.lookupBank(dir, prefix)
+	if err != nil {
+		return err
+	}
+	if b.Name == "" {
+		b.Name = dir_b.Name
+	}
+	if b.Place == "" {
+		b.Place = dir_b.Place
+	}
+	if b.Account == "" {
+		b.Account = dir_b.Account
+	}
+	return nil
+}
+
+// BankFiller is implemented by documents filling their bank values from a directory.
+type BankFiller interface {
+	FillBanks(dir BankDirectory) error
+}
+
+// FillBanks fills empty payer and receiver bank values from the directory.
+func (d *PPDocument) FillBanks(dir BankDirectory) error {
+	err := errors.Join(d.Payer.Bank.FillBank(dir, PAYER_PREFIX),
+		d.Receiver.Bank.FillBank(dir, RECEIVER_PREFIX),
+	)
+	if err != nil {
+		return fmt.Errorf("document %d: %w", d.Num, err)
+	}
+	return nil
+}
+
+// fillBanks fills bank values of all documents from the export directory.
+func (e *BankExport) fillBanks() error {
+	if e.BankDirectory == nil {
+		return nil
+	}
+	errs := make([]error, 0)
+	for _, doc := range e.Documents {
+		if d, ok := doc.(BankFiller); ok {
+			if err := d.FillBanks(e.BankDirectory); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkBanks checks payer and receiver banks of all documents
+// in the export directory, documents are not changed.
+func (e *BankExport) checkBanks() error {
+	if e.BankDirectory == nil {
+		return nil
+	}
+	errs := make([]error, 0)
+	for _, d := range e.Documents {
+		payer, receiver := d.GetPayer(), d.GetReceiver()
+		err := errors.Join(payer.Bank.CheckBank(e.BankDirectory, PAYER_PREFIX),
+			receiver.Bank.CheckBank(e.BankDirectory, RECEIVER_PREFIX),
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("document %d: %w", d.GetNum(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package clbnk
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadED807(t *testing.T) {
+	dir, err := LoadED807File("ed807.xml")
+	if err != nil {
+		t.Fatalf("LoadED807File failed: %v", err)
+	}
+	if len(dir) != 4 {
+		t.Fatalf("directory size, expected 4, got %d", len(dir))
+	}
+	b, ok := dir.Lookup("044525225")
+	if !ok || b.Name != "ПАО Сбербанк" || b.Place != "г. Москва" || b.Account != "30101810400000000225" || b.Closed {
+		t.Fatalf("bank 044525225, got %+v", b)
+	}
+	if b, _ := dir.Lookup("044525593"); b.Account != "30101810200000000593" || b.Name != `АО "АЛЬФА-БАНК"` {
+		t.Fatalf("bank 044525593, got %+v", b)
+	}
+	if b, _ := dir.Lookup("044525111"); !b.Closed {
+		t.Fatal("bank with revoked license must be closed")
+	}
+	if b, _ := dir.Lookup("046577222"); !b.Closed {
+		t.Fatal("deleted bank must be closed")
+	}
+	if _, ok := dir.Lookup("000000000"); ok {
+		t.Fatal("unknown BIK must not be found")
+	}
+}
+
+func TestExportBankDirectory(t *testing.T) {
+	dir, err := LoadED807File("ed807.xml")
+	if err != nil {
+		t.Fatalf("LoadED807File failed: %v", err)
+	}
+	doc := &PPDocument{Num: 1, Date: time.Now(), Sum: 100, Order: 5, PayComment: "За товары",
+		Payer: Party{Name: `ООО "Рога и Копыта"`, Inn: "1234567891", Account: "40702810000000000001",
+			Bank: BankInfo{Bik: "044525225"},
+		},
+		Receiver: Party{Name: `ИП Иванов А.А.`, Inn: "111122223344", Account: "40802810000000000002",
+			Bank: BankInfo{Bik: "044525593", Name: "Альфа-Банк"},
+		},
+	}
+	exp := NewBankExport([]BankExportDocument{doc})
+	exp.BankDirectory = dir
+	if err := exp.Validate(); err != nil || doc.Payer.Bank.Name != "" {
+		t.Fatalf("Validate must not fill bank values: %v, %+v", err, doc.Payer.Bank)
+	}
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if doc.Payer.Bank.Name != "ПАО Сбербанк" || doc.Payer.Bank.Place != "г. Москва" || doc.Payer.Bank.Account != "30101810400000000225" {
+		t.Fatalf("payer bank from directory, got %+v", doc.Payer.Bank)
+	}
+	//given values are kept
+	if doc.Receiver.Bank.Name != "Альфа-Банк" || doc.Receiver.Bank.Account != "30101810200000000593" {
+		t.Fatalf("receiver bank from directory, got %+v", doc.Receiver.Bank)
+	}
+
+	doc.Receiver.Bank = BankInfo{Bik: "044525111"}
+	doc.Payer.Bank.Bik = "000000000"
+	err = exp.Validate()
+	if err == nil {
+		t.Fatal("Validate with closed and unknown banks must fail")
+	}
+	for _, s := range []string{"ПолучательБИК: bank 044525111", "closed", "ПлательщикБИК: bank 000000000 not found"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Fatalf("Validate error must contain %s: %v", s, err)
+		}
+	}
+	if _, err := exp.Marshal(); err == nil {
+		t.Fatal("Marshal with closed bank must fail")
+	}
+}
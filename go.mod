@@ -2,4 +2,4 @@ module github.com/dronm/clbnk
 
 go 1.21.3
 
-require golang.org/x/text v0.15.0 // indirect
+require golang.org/x/text v0.15.0
@@ -0,0 +1,287 @@
+package clbnk
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Invoice references in payment purposes, for example
+// "Оплата по счету №125 от 09.01.2024 на сумму 175000-00, в том числе НДС (20%) 29166-67".
+
+// InvoiceRef is an invoice reference found in a payment purpose.
+// Zero values mean the value is not given.
+type InvoiceRef struct {
+	Num    string
+	Date   time.Time
+	Sum    float64
+	VatSum float64
+}
+
+// InvoiceExtractor finds invoice references by regular expressions.
+// RefExp finds an invoice number in its first group, references after
+// account wordings like "расчетный счет" are skipped. NextRefExp finds
+// other numbers of the reference in the text following it, for example ", №126".
+// DateExp, SumExp and VatExp find values in their first groups in the text
+// between the reference and the next reference. If VatExp is nil,
+// VAT amount is found by ParseVat, as in VAT checks of documents.
+type InvoiceExtractor struct {
+	RefExp     *regexp.Regexp
+	NextRefExp *regexp.Regexp
+	DateExp    *regexp.Regexp
+	SumExp     *regexp.Regexp
+	VatExp     *regexp.Regexp
+}
+
+// amount with kopecks separated by point, comma or dash: 175000-00, 1 500,50
+const invoiceAmountExp = `((?:\d{1,3}(?:[ \x{00a0}]\d{3})+|\d+)(?:[.,-]\d{1,2})?)`
+
+// invoiceAccountExp finds account wordings before "счет": "на расчетный счет №4070...",
+// \b does not work with Cyrillic letters.
+var invoiceAccountExp = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:расч[её]тн|лицев|корреспондентск|ссудн|депозитн|транзитн|валютн)[а-яё]*\s*$`)
+
+// NewInvoiceExtractor creates an extractor of common purpose wordings.
+func NewInvoiceExtractor() *InvoiceExtractor {
+	return &InvoiceExtractor{
+		RefExp:     regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:сч[её]т[а-яё]*(?:-фактур[а-яё]*)?|сч\.|сч/ф|инвойс[а-яё]*|invoice)\s*(?:на\s+оплату\s*)?(?:№|N|#)\s*([0-9A-Za-zА-Яа-яЁё/_-]*[0-9A-Za-zА-Яа-яЁё])`),
+		NextRefExp: regexp.MustCompile(`(?:,|;|\s+и)\s*(?:№|N|#)\s*([0-9A-Za-zА-Яа-яЁё/_-]*[0-9A-Za-zА-Яа-яЁё])`),
+		DateExp:    regexp.MustCompile(`(?i)от\s*(\d{1,2}[./]\d{1,2}[./]\d{2,4})`),
+		SumExp:     regexp.MustCompile(`(?i)(?:на\s+сумму|сумма|сумму)\s*:?\s*` + invoiceAmountExp),
+	}
+}
+
+// parseAmount parses amounts with spaces and kopecks separated by point, comma or dash.
+func parseAmount(s string) (float64, error) {
+	s = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".", "-", ".").Replace(strings.TrimSpace(s))
+	return strconv.ParseFloat(s, 64)
+}
+
+// parsePurposeDate parses dates like 09.01.2024, 9/1/24.
+func parsePurposeDate(s string) (time.Time, bool) {
+	s = strings.ReplaceAll(s, "/", ".")
+	for _, layout := range []string{"02.01.2006", "2.1.2006", "02.01.06", "2.1.06"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func submatch(exp *regexp.Regexp, s string) string {
+	if exp == nil {
+		return ""
+	}
+	if m := exp.FindStringSubmatch(s); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// Extract returns invoice references of the purpose in the text order.
+func (x *InvoiceExtractor) Extract(purpose string) []InvoiceRef {
+	type refPos struct {
+		num        string
+		start, end int
+	}
+	positions := make([]refPos, 0)
+	matches := x.RefExp.FindAllStringSubmatchIndex(purpose, -1)
+	for i, m := range matches {
+		//account number, not an invoice
+		if invoiceAccountExp.MatchString(purpose[:m[0]]) {
+			continue
+		}
+		positions = append(positions, refPos{num: purpose[m[2]:m[3]], start: m[0], end: m[1]})
+		//numbers following the reference up to the next reference
+		end, limit := m[1], len(purpose)
+		if i+1 < len(matches) {
+			limit = matches[i+1][0]
+		}
+		for x.NextRefExp != nil {
+			n := x.NextRefExp.FindStringSubmatchIndex(purpose[end:limit])
+			if n == nil {
+				break
+			}
+			positions = append(positions, refPos{num: purpose[end+n[2] : end+n[3]], start: end + n[0], end: end + n[1]})
+			end += n[1]
+		}
+	}
+
+	refs := make([]InvoiceRef, 0, len(positions))
+	for i, p := range positions {
+		seg_end := len(purpose)
+		if i+1 < len(positions) {
+			seg_end = positions[i+1].start
+		}
+		seg := purpose[p.end:seg_end]
+		ref := InvoiceRef{Num: p.num}
+		if d, ok := parsePurposeDate(submatch(x.DateExp, seg)); ok {
+			ref.Date = d
+		}
+		if s := submatch(x.SumExp, seg); s != "" {
+			ref.Sum, _ = parseAmount(s)
+		}
+		if x.VatExp == nil {
+			if vat, ok := ParseVat(seg); ok {
+				ref.VatSum = vat.Sum
+			}
+		} else if s := submatch(x.VatExp, seg); s != "" {
+			ref.VatSum, _ = parseAmount(s)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// Invoice is an open invoice for matching incoming payments.
+type Invoice struct {
+	Num  string
+	Date time.Time // optional, compared if given in the purpose
+	Inn  string    // customer INN, optional, compared with payer INN
+	Sum  float64
+	Paid float64 // updated by matching
+}
+
+// Remaining returns the unpaid sum, zero for paid and overpaid invoices.
+func (inv *Invoice) Remaining() float64 {
+	if r := inv.Sum - inv.Paid; r > BALANCE_EPS {
+		return r
+	}
+	return 0
+}
+
+// Overpaid returns the sum paid over the invoice sum.
+func (inv *Invoice) Overpaid() float64 {
+	if r := inv.Paid - inv.Sum; r > BALANCE_EPS {
+		return r
+	}
+	return 0
+}
+
+// InvoiceAllocation is a part of a payment applied to an invoice.
+type InvoiceAllocation struct {
+	Invoice *Invoice
+	Sum     float64
+}
+
+// InvoiceMatch is a result of matching an incoming payment.
+type InvoiceMatch struct {
+	Document    BankImportDocument
+	Refs        []InvoiceRef // references found in the purpose
+	Allocations []InvoiceAllocation
+	Overpayment float64 // sum over remaining sums of the invoices, added to the last invoice
+	ByAmount    bool    // matched without references by payer INN and sum
+}
+
+// InvoiceMatcher links incoming payments to open invoices.
+// Invoices are found by numbers of purpose references. A payment is applied
+// to its invoices in the purpose order up to their remaining sums or reference
+// sums. Payments without found references are matched by the payer INN to
+// the only invoice with the remaining sum equal to the payment sum.
+type InvoiceMatcher struct {
+	Extractor *InvoiceExtractor
+	Invoices  []*Invoice
+}
+
+// NewInvoiceMatcher creates a matcher of the invoices with the default extractor.
+func NewInvoiceMatcher(invoices []*Invoice) *InvoiceMatcher {
+	return &InvoiceMatcher{Extractor: NewInvoiceExtractor(), Invoices: invoices}
+}
+
+// normalizeInvoiceNum returns the number in lower case without leading zeros.
+func normalizeInvoiceNum(num string) string {
+	num = strings.ToLower(strings.TrimSpace(num))
+	if t := strings.TrimLeft(num, "0"); t != "" {
+		return t
+	}
+	return num
+}
+
+func (m *InvoiceMatcher) findInvoice(ref *InvoiceRef, payerInn string) *Invoice {
+	num := normalizeInvoiceNum(ref.Num)
+	for _, inv := range m.Invoices {
+		if normalizeInvoiceNum(inv.Num) != num {
+			continue
+		}
+		if !ref.Date.IsZero() && !inv.Date.IsZero() && !sameDay(ref.Date, inv.Date) {
+			continue
+		}
+		if inv.Inn != "" && payerInn != "" && inv.Inn != payerInn {
+			continue
+		}
+		return inv
+	}
+	return nil
+}
+
+// findByAmount returns the only invoice of the payer with the remaining sum.
+func (m *InvoiceMatcher) findByAmount(sum float64, payerInn string) *Invoice {
+	var res *Invoice
+	for _, inv := range m.Invoices {
+		if inv.Inn == "" || inv.Inn != payerInn || !sameSum(inv.Remaining(), sum) {
+			continue
+		}
+		if res != nil {
+			return nil
+		}
+		res = inv
+	}
+	return res
+}
+
+// Match matches the payments, Paid values of the invoices are updated.
+func (m *InvoiceMatcher) Match(docs []BankImportDocument) []InvoiceMatch {
+	res := make([]InvoiceMatch, 0, len(docs))
+	for _, doc := range docs {
+		match := InvoiceMatch{Document: doc, Refs: m.Extractor.Extract(doc.GetPurpose())}
+		payer_inn := doc.GetPayer().Inn
+
+		invoices := make([]*Invoice, 0)
+		limits := make([]float64, 0)
+		for i := range match.Refs {
+			if inv := m.findInvoice(&match.Refs[i], payer_inn); inv != nil {
+				invoices = append(invoices, inv)
+				limits = append(limits, match.Refs[i].Sum)
+			}
+		}
+		if len(invoices) == 0 {
+			if inv := m.findByAmount(doc.GetSum(), payer_inn); inv != nil {
+				invoices = append(invoices, inv)
+				limits = append(limits, 0)
+				match.ByAmount = true
+			}
+		}
+
+		rest := doc.GetSum()
+		for i, inv := range invoices {
+			if rest < BALANCE_EPS {
+				break
+			}
+			sum := inv.Remaining()
+			if limits[i] > 0 && limits[i] < sum {
+				sum = limits[i]
+			}
+			if sum > rest {
+				sum = rest
+			}
+			if sum < BALANCE_EPS {
+				continue
+			}
+			inv.Paid += sum
+			rest -= sum
+			match.Allocations = append(match.Allocations, InvoiceAllocation{Invoice: inv, Sum: sum})
+		}
+		if len(invoices) > 0 && rest >= BALANCE_EPS {
+			match.Overpayment = rest
+			last := invoices[len(invoices)-1]
+			last.Paid += rest
+			if n := len(match.Allocations); n > 0 && match.Allocations[n-1].Invoice == last {
+				match.Allocations[n-1].Sum += rest
+			} else {
+				match.Allocations = append(match.Allocations, InvoiceAllocation{Invoice: last, Sum: rest})
+			}
+		}
+		res = append(res, match)
+	}
+	return res
+}
@@ -0,0 +1,96 @@
+package clbnk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractInvoices(t *testing.T) {
+	x := NewInvoiceExtractor()
+	tests := []struct {
+		purpose string
+		refs    []InvoiceRef
+	}{
+		{"За товары, по счету №125 на сумму 175000-00", []InvoiceRef{{Num: "125", Sum: 175000}}},
+		{"Оплата по счету № 777 от 09.01.2024 на сумму 375-25\nВ том числе НДС (20%) 62-54",
+			[]InvoiceRef{{Num: "777", Date: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), Sum: 375.25, VatSum: 62.54}},
+		},
+		{"Оплата по счетам №12 от 01.02.2024 на сумму 1 200,00, №13 от 02.02.2024 на сумму 300,00 НДС не облагается",
+			[]InvoiceRef{{Num: "12", Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Sum: 1200},
+				{Num: "13", Date: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), Sum: 300},
+			},
+		},
+		{"Оплата по сч/ф N А-15/2, НДС 20% - 100.00", []InvoiceRef{{Num: "А-15/2", VatSum: 100}}},
+		{"Оплата по заказу клиента №9614, НДС не облагается", []InvoiceRef{}},
+		//"счет" as a part of a word and account numbers
+		{"Оплата по расчету №5", []InvoiceRef{}},
+		{"Возврат по расчёту № 12", []InvoiceRef{}},
+		{"Перечисление на расчетный счет №40702810000000000001", []InvoiceRef{}},
+		{"Перечисление на расчетный счет №40702810000000000001 по счету №7", []InvoiceRef{{Num: "7"}}},
+		//VAT amount as found by ParseVat
+		{"Оплата по счету №5, в т.ч. НДС 150-00", []InvoiceRef{{Num: "5", VatSum: 150}}},
+		{"Оплата по счету №6, НДС 150-00", []InvoiceRef{{Num: "6"}}},
+	}
+	for _, tt := range tests {
+		refs := x.Extract(tt.purpose)
+		if len(refs) != len(tt.refs) {
+			t.Fatalf("%q: expected %d references, got %+v", tt.purpose, len(tt.refs), refs)
+		}
+		for i := range refs {
+			if refs[i] != tt.refs[i] {
+				t.Fatalf("%q: reference %d, expected %+v, got %+v", tt.purpose, i, tt.refs[i], refs[i])
+			}
+			if vat, _ := ParseVat(tt.purpose); len(refs) == 1 && vat.Sum != refs[i].VatSum {
+				t.Fatalf("%q: reference VAT %f differs from ParseVat %f", tt.purpose, refs[i].VatSum, vat.Sum)
+			}
+		}
+	}
+}
+
+func TestMatchInvoices(t *testing.T) {
+	const CUSTOMER_INN = "7123456789"
+	inv125 := &Invoice{Num: "125", Inn: CUSTOMER_INN, Sum: 1000}
+	inv126 := &Invoice{Num: "126", Inn: CUSTOMER_INN, Sum: 500}
+	inv127 := &Invoice{Num: "00127", Date: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), Sum: 300}
+	inv128 := &Invoice{Num: "128", Inn: CUSTOMER_INN, Sum: 250}
+	newDoc := func(sum float64, purpose string) BankImportDocument {
+		return &PPDocument{Sum: sum, PayComment: purpose, Payer: Party{Inn: CUSTOMER_INN}}
+	}
+	docs := []BankImportDocument{
+		newDoc(400, "Оплата по счету №125, частичная оплата"),
+		newDoc(700, "Оплата по счету №125"),
+		newDoc(500, "Оплата по счетам №126 на сумму 200-00, №127 от 09.01.2024"),
+		newDoc(250, "Оплата за услуги"),
+		newDoc(50, "Оплата по счету №999"),
+	}
+	res := NewInvoiceMatcher([]*Invoice{inv125, inv126, inv127, inv128}).Match(docs)
+	if len(res) != len(docs) {
+		t.Fatalf("match count, expected %d, got %d", len(docs), len(res))
+	}
+	//partial payment
+	if a := res[0].Allocations; len(a) != 1 || a[0].Invoice != inv125 || a[0].Sum != 400 {
+		t.Fatalf("partial payment allocations, got %+v", a)
+	}
+	//overpayment
+	if a := res[1].Allocations; len(a) != 1 || a[0].Sum != 700 || res[1].Overpayment != 100 {
+		t.Fatalf("overpayment allocations, got %+v, %f", a, res[1].Overpayment)
+	}
+	if inv125.Remaining() != 0 || inv125.Overpaid() != 100 {
+		t.Fatalf("overpaid invoice, remaining %f, overpaid %f", inv125.Remaining(), inv125.Overpaid())
+	}
+	//several invoices, reference sum limits the first one
+	if a := res[2].Allocations; len(a) != 2 || a[0].Invoice != inv126 || a[0].Sum != 200 || a[1].Invoice != inv127 || a[1].Sum != 300 {
+		t.Fatalf("several invoices allocations, got %+v", a)
+	}
+	if inv126.Remaining() != 300 {
+		t.Fatalf("invoice 126 remaining, expected 300, got %f", inv126.Remaining())
+	}
+	//by amount without references
+	if a := res[3].Allocations; !res[3].ByAmount || len(a) != 1 || a[0].Invoice != inv128 {
+		t.Fatalf("match by amount, got %+v", res[3])
+	}
+	//unknown invoice
+	if len(res[4].Refs) != 1 || len(res[4].Allocations) != 0 {
+		t.Fatalf("unknown invoice, got %+v", res[4])
+	}
+}
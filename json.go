@@ -0,0 +1,143 @@
+package clbnk
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSON representation.
+// Documents are JSON objects with their document type in "type" field,
+// for example {"type":"Платежное поручение","num":1,...}.
+// Dates are RFC 3339 strings, sums are numbers in rubles with kopecks
+// as a decimal fraction, enumerations are their exchange format values.
+
+const JSON_TYPE_FIELD = "type"
+
+type bankImportJSON BankImport
+type bankExportJSON BankExport
+
+// MarshalJSON implements json.Marshaler. The value receiver makes
+// values and fields of the type marshaled with document types too.
+func (e BankImport) MarshalJSON() ([]byte, error) {
+	docs := make([]json.RawMessage, 0, len(e.Documents))
+	for _, doc := range e.Documents {
+		b, err := marshalJSONDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, b)
+	}
+	return json.Marshal(struct {
+		*bankImportJSON
+		Documents []json.RawMessage `json:"documents"`
+	}{(*bankImportJSON)(&e), docs})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *BankImport) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*bankImportJSON
+		Documents []json.RawMessage `json:"documents"`
+	}{bankImportJSON: (*bankImportJSON)(e)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	e.Documents = make([]BankImportDocument, 0, len(v.Documents))
+	for i, b := range v.Documents {
+		doc, err := unmarshalJSONDocument(b)
+		if err != nil {
+			return fmt.Errorf("document %d: %v", i, err)
+		}
+		imp_doc, ok := doc.(BankImportDocument)
+		if !ok {
+			return fmt.Errorf("document %d: %T is not an import document", i, doc)
+		}
+		e.Documents = append(e.Documents, imp_doc)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The value receiver makes
+// values and fields of the type marshaled with document types too.
+func (e BankExport) MarshalJSON() ([]byte, error) {
+	docs := make([]json.RawMessage, 0, len(e.Documents))
+	for _, doc := range e.Documents {
+		b, err := marshalJSONDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, b)
+	}
+	return json.Marshal(struct {
+		*bankExportJSON
+		Documents []json.RawMessage `json:"documents"`
+	}{(*bankExportJSON)(&e), docs})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *BankExport) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*bankExportJSON
+		Documents []json.RawMessage `json:"documents"`
+	}{bankExportJSON: (*bankExportJSON)(e)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	e.Documents = make([]BankExportDocument, 0, len(v.Documents))
+	for i, b := range v.Documents {
+		doc, err := unmarshalJSONDocument(b)
+		if err != nil {
+			return fmt.Errorf("document %d: %v", i, err)
+		}
+		exp_doc, ok := doc.(BankExportDocument)
+		if !ok {
+			return fmt.Errorf("document %d: %T is not an export document", i, doc)
+		}
+		e.Documents = append(e.Documents, exp_doc)
+	}
+	return nil
+}
+
+// marshalJSONDocument marshals the document with its type as the first field.
+func marshalJSONDocument(doc interface{ GetType() DocumentType }) (json.RawMessage, error) {
+	tp, err := json.Marshal(doc.GetType())
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 2 || b[0] != '{' {
+		return nil, fmt.Errorf("document %T must be marshaled to a JSON object", doc)
+	}
+	res := []byte(`{"` + JSON_TYPE_FIELD + `":`)
+	res = append(res, tp...)
+	if string(b) != "{}" {
+		res = append(res, ',')
+	}
+	return append(res, b[1:]...), nil
+}
+
+// unmarshalJSONDocument creates a document of the type given in "type" field.
+func unmarshalJSONDocument(data []byte) (interface{}, error) {
+	var head struct {
+		Type *DocumentType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+	if head.Type == nil {
+		return nil, fmt.Errorf("document type field %q not found", JSON_TYPE_FIELD)
+	}
+	doc_type, ok := importDocumentMaps[*head.Type]
+	if !ok {
+		return nil, fmt.Errorf("document type not supported: %s", head.Type)
+	}
+	doc := reflect.New(doc_type).Interface()
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
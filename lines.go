@@ -0,0 +1,102 @@
+package clbnk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	PURPOSE_MAX_LEN = 210 // maximum length of НазначениеПлатежа
+	DEF_LINE_WIDTH  = 35  // default line width of multiline values
+)
+
+// vatPhraseExp finds VAT phrases of payment purpose,
+// every phrase is started on a new line.
+var vatPhraseExp = regexp.MustCompile(`(?i)\s*(в\s+том\s+числе\s+ндс|ндс\s+не\s+облагается|без\s+ндс|без\s+налога\s+\(ндс\))`)
+
+// joinLines returns one line value of a multiline value.
+func joinLines(value string) string {
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+// WrapLines splits the value into at most n lines of the given width.
+// Explicit new lines are kept, VAT phrases are started on new lines,
+// long lines are wrapped by words. Words longer than the width are split.
+// If paragraphs do not fit into n lines, the value is wrapped as a single
+// paragraph. If it still does not fit, the one line value is split
+// at the width regardless of words, so any value of at most n*width
+// characters fits. An error is returned if the value is longer.
+func WrapLines(value string, n, width int) ([]string, error) {
+	if n <= 0 || width <= 0 {
+		return nil, fmt.Errorf("invalid line count %d or width %d", n, width)
+	}
+	src := value
+	value = vatPhraseExp.ReplaceAllString(value, "\n$1")
+	value = strings.TrimPrefix(value, "\n")
+
+	lines := make([]string, 0, n)
+	for _, p := range strings.Split(value, "\n") {
+		lines = append(lines, wrapParagraph(p, width)...)
+	}
+	if len(lines) <= n {
+		return lines, nil
+	}
+
+	lines = wrapParagraph(joinLines(value), width)
+	if len(lines) <= n {
+		return lines, nil
+	}
+
+	lines = splitLine(joinLines(src), width)
+	if len(lines) > n {
+		return nil, fmt.Errorf("value does not fit into %d lines of %d characters", n, width)
+	}
+	return lines, nil
+}
+
+// splitLine splits the value into lines of the width.
+func splitLine(value string, width int) []string {
+	lines := make([]string, 0)
+	r := []rune(value)
+	for len(r) > width {
+		lines = append(lines, string(r[:width]))
+		r = r[width:]
+	}
+	if len(r) > 0 {
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+// wrapParagraph wraps one paragraph by words.
+func wrapParagraph(p string, width int) []string {
+	lines := make([]string, 0)
+	line := ""
+	line_len := 0
+	for _, w := range strings.Fields(p) {
+		w_len := utf8.RuneCountInString(w)
+		if line_len > 0 && line_len+1+w_len <= width {
+			line += " " + w
+			line_len += 1 + w_len
+			continue
+		}
+		if line_len > 0 {
+			lines = append(lines, line)
+		}
+		//split long words
+		for w_len > width {
+			r := []rune(w)
+			lines = append(lines, string(r[:width]))
+			w = string(r[width:])
+			w_len -= width
+		}
+		line = w
+		line_len = w_len
+	}
+	if line_len > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -5,14 +5,19 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 type Marshaler interface {
 	Marshal() ([]byte, error)
 }
 
+// FirmMarshaler is implemented by documents generating composite
+// firm names (Плательщик, Получатель) for their party blocks.
+// The prefix identifies the party block.
 type FirmMarshaler interface {
-	MarshalFirmName() ([]byte, error)
+	MarshalFirmName(prefix string) ([]byte, error)
 }
 
 type MarshalTime interface {
@@ -37,7 +42,7 @@ func marshal(data interface{}, elemStart, elemEnd string) ([]byte, error) {
 	// fmt.Printf("Kind=%s val: %+v\n", v.Kind(), data)
 	switch v.Kind() {
 	case reflect.Struct:
-		return marshalStruct(v)
+		return marshalStruct(v, "", nil)
 
 	case reflect.Slice:
 		return marshalSlice(v, elemStart, elemEnd)
@@ -59,6 +64,7 @@ func marshal(data interface{}, elemStart, elemEnd string) ([]byte, error) {
 
 // If elemStart/elemEnd defined then every slice element is
 // prefixed/postfixed with elemStart/elemEnd values.
+// Document elements with elemStart ending with = get their type as the value.
 func marshalSlice(v reflect.Value, elemStart, elemEnd string) ([]byte, error) {
 	var buf bytes.Buffer
 
@@ -72,6 +78,16 @@ func marshalSlice(v reflect.Value, elemStart, elemEnd string) ([]byte, error) {
 			if _, err := buf.WriteString(elemStart); err != nil {
 				return []byte{}, err
 			}
+			//document sections are started with the document type
+			if d, ok := slice_elem.Interface().(BankExportDocument); ok && strings.HasSuffix(elemStart, "=") {
+				tp, err := d.GetType().Marshal()
+				if err != nil {
+					return []byte{}, err
+				}
+				if _, err := buf.Write(append(tp, []byte("\r\n")...)); err != nil {
+					return []byte{}, err
+				}
+			}
 		}
 		if _, err := buf.Write(cont); err != nil {
 			return []byte{}, err
@@ -85,28 +101,59 @@ func marshalSlice(v reflect.Value, elemStart, elemEnd string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func marshalStruct(v reflect.Value) ([]byte, error) {
+// marshalStruct marshals all struct fields. Field names are prefixed
+// with the prefix value. Struct fields with bankPrefix tag are marshaled
+// inline with their own fields prefixed with the tag value.
+// Empty firm name fields are generated by firmM, which is the closest
+// struct implementing FirmMarshaler.
+func marshalStruct(v reflect.Value, prefix string, firmM FirmMarshaler) ([]byte, error) {
 	var buf bytes.Buffer
-	var firm_name []byte
-	if firm_m, ok := v.Interface().(FirmMarshaler); ok {
-		var err error
-		firm_name, err = firm_m.MarshalFirmName()
-		if err != nil {
-			return []byte{}, err
+	if m, ok := v.Interface().(FirmMarshaler); ok {
+		firmM = m
+	} else if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(FirmMarshaler); ok {
+			firmM = m
 		}
 	}
 	// Iterate over struct fields
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Type().Field(i)
+		if field.Tag.Get("bank") == "-" {
+			continue
+		}
+		if field.Tag.Get("bankOmitEmpty") == "1" && v.Field(i).IsZero() {
+			continue
+		}
+		if field_prefix, ok := field.Tag.Lookup("bankPrefix"); ok {
+			b, err := marshalStruct(v.Field(i), prefix+field_prefix, firmM)
+			if err != nil {
+				return []byte{}, err
+			}
+			if _, err := buf.Write(b); err != nil {
+				return []byte{}, err
+			}
+			continue
+		}
+
 		field_name := field.Tag.Get("bank")
 		field_is_firm := field.Tag.Get("bankFirmName")
+		if field_is_firm == "1" {
+			//firm name is marshaled with the prefix as its name
+			field_name = prefix
+		} else if field_name != "" {
+			field_name = prefix + field_name
+		}
 
 		field_elem_start := field.Tag.Get("bankElemStart")
 		field_elem_end := field.Tag.Get("bankElemEnd")
 
 		var field_val []byte
-		if field_is_firm == "1" {
-			field_val = firm_name
+		if field_is_firm == "1" && v.Field(i).String() == "" && firmM != nil {
+			var err error
+			field_val, err = firmM.MarshalFirmName(prefix)
+			if err != nil {
+				return []byte{}, err
+			}
 		} else {
 			var err error
 			field_val, err = marshal(v.Field(i).Interface(), field_elem_start, field_elem_end)
@@ -127,29 +174,35 @@ func marshalStruct(v reflect.Value) ([]byte, error) {
 			if err != nil {
 				return []byte{}, err
 			}
-			last_line := make([]byte, 0)
-			lines := bytes.Split(field_val, []byte("\n"))
-			b := marshalField(field_name, bytes.Join(lines, []byte{' '})) //all lines with a space
-			if _, err := buf.Write(b); err != nil {
-				return []byte{}, err
-			}
-			for j, l := range lines {
-				if j+1 >= n {
-					last_line = append(last_line, ' ')
-					last_line = append(last_line, l...)
-					continue
+			width := DEF_LINE_WIDTH
+			if w := field.Tag.Get("lineWidth"); w != "" {
+				if width, err = strconv.Atoi(w); err != nil {
+					return []byte{}, err
 				}
-				b := marshalField(fmt.Sprintf("%s%d", field_name, j+1), l)
-				if _, err := buf.Write(b); err != nil {
+			}
+			val := joinLines(string(field_val)) //all lines with a space
+			if m := field.Tag.Get("maxLen"); m != "" {
+				max_len, err := strconv.Atoi(m)
+				if err != nil {
 					return []byte{}, err
 				}
+				if l := utf8.RuneCountInString(val); l > max_len {
+					return []byte{}, fmt.Errorf("%s: value length %d exceeds %d characters", field_name, l, max_len)
+				}
 			}
-			if len(last_line) > 0 {
-				b := marshalField(fmt.Sprintf("%s%d", field_name, n), last_line)
+			lines, err := WrapLines(string(field_val), n, width)
+			if err != nil {
+				return []byte{}, fmt.Errorf("%s: %v", field_name, err)
+			}
+			b := marshalField(field_name, []byte(val))
+			if _, err := buf.Write(b); err != nil {
+				return []byte{}, err
+			}
+			for j, l := range lines {
+				b := marshalField(fmt.Sprintf("%s%d", field_name, j+1), []byte(l))
 				if _, err := buf.Write(b); err != nil {
 					return []byte{}, err
 				}
-
 			}
 		}
 	}
@@ -171,10 +224,7 @@ func marshalField(fieldName string, fieldVal []byte) []byte {
 	return fieldVal
 }
 
-// custom marshalling of a firm name
+// custom marshalling of a firm name: ИНН + space + inn + space + name
 func marshalFirmName(inn, name string) []byte {
-	//ИНН(3 chars) + space + p.Inn + space + p.Name
-	b := make([]byte, 3+1+len(inn)+1+len(name))
-	copy(b, []byte("ИНН "+inn+" "+name))
-	return b
+	return []byte("ИНН " + inn + " " + name)
 }
@@ -0,0 +1,204 @@
+package clbnk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PaymentStatus is a status of a sent payment found by statement matching.
+type PaymentStatus int
+
+const (
+	PAYMENT_STATUS_PENDING     PaymentStatus = iota // not executed yet, may be executed later
+	PAYMENT_STATUS_EXECUTED                         // found in the statement with ДатаСписано
+	PAYMENT_STATUS_MISSING                          // not found in the statement covering the payment date
+	PAYMENT_STATUS_NOT_COVERED                      // not found, dated before the statement period
+)
+
+func PaymentStatusValues() []string {
+	return []string{"Ожидает исполнения",
+		"Исполнен",
+		"Не найден",
+		"Вне периода выписки",
+	}
+}
+
+// String returns the status description.
+func (s PaymentStatus) String() string {
+	v := PaymentStatusValues()
+	if s < 0 || int(s) >= len(v) {
+		return fmt.Sprintf("PaymentStatus(%d)", int(s))
+	}
+	return v[int(s)]
+}
+
+// PaymentMatch is a result of matching a sent payment.
+type PaymentMatch struct {
+	Payment  *PPDocument
+	Document BankImportDocument // statement document, nil if not found
+	Status   PaymentStatus
+	ExecDate time.Time // ДатаСписано of the statement document
+	Fuzzy    bool      // found by fuzzy matching
+}
+
+// PaymentMatcher matches sent payments against statement documents.
+// A payment matches a document with the same number, date, sum,
+// payer and receiver accounts. Payments not matched are looked for among
+// other documents with the same sum and payer account, the receiver account
+// or INN, the date not farther than DateTolerance days and the purpose
+// similarity not less than MinSimilarity. The most similar document is taken.
+type PaymentMatcher struct {
+	DateTolerance int     // days
+	MinSimilarity float64 // 0..1, share of common purpose words
+}
+
+// NewPaymentMatcher creates a matcher with 5 days tolerance and 0.3 purpose similarity.
+func NewPaymentMatcher() *PaymentMatcher {
+	return &PaymentMatcher{DateTolerance: 5, MinSimilarity: 0.3}
+}
+
+// documentKreditDate returns ДатаСписано of built-in documents.
+func documentKreditDate(doc BankImportDocument) time.Time {
+	switch d := doc.(type) {
+	case *PPDocument:
+		return d.KreditDate
+	case *BankOrderDocument:
+		return d.KreditDate
+	}
+	return time.Time{}
+}
+
+func sameSum(a, b float64) bool {
+	return math.Abs(a-b) < BALANCE_EPS
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// dayDistance returns absolute number of days between dates.
+func dayDistance(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	d := int(a.Sub(b).Hours() / 24)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// purposeWords returns lower case words of the purpose.
+func purposeWords(s string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[w] = struct{}{}
+	}
+	return words
+}
+
+// purposeSimilarity returns the share of common words of two purposes.
+func purposeSimilarity(a, b string) float64 {
+	wa, wb := purposeWords(a), purposeWords(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	common := 0
+	for w := range wa {
+		if _, ok := wb[w]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(wa)+len(wb)-common)
+}
+
+func (m *PaymentMatcher) exactMatch(p *PPDocument, doc BankImportDocument) bool {
+	return p.Num == doc.GetNum() &&
+		sameDay(p.Date, doc.GetDate()) &&
+		sameSum(p.Sum, doc.GetSum()) &&
+		p.Payer.Account == doc.GetPayer().Account &&
+		p.Receiver.Account == doc.GetReceiver().Account
+}
+
+// fuzzyScore returns purpose similarity of a fuzzy match candidate, -1 if it does not match.
+func (m *PaymentMatcher) fuzzyScore(p *PPDocument, doc BankImportDocument) float64 {
+	rec := doc.GetReceiver()
+	if !sameSum(p.Sum, doc.GetSum()) || p.Payer.Account != doc.GetPayer().Account ||
+		(p.Receiver.Account != rec.Account && (p.Receiver.Inn == "" || p.Receiver.Inn != rec.Inn)) ||
+		dayDistance(p.Date, doc.GetDate()) > m.DateTolerance {
+		return -1
+	}
+	sim := purposeSimilarity(p.PayComment, doc.GetPurpose())
+	if sim < m.MinSimilarity {
+		return -1
+	}
+	return sim
+}
+
+// Match returns match results for all payments in the payments order.
+// Every statement document is matched once. Found payments are executed
+// if the document has ДатаСписано, pending otherwise. Payments not found
+// are missing if their date is within the statement period before its end date,
+// not covered if their date is before the period, pending otherwise.
+func (m *PaymentMatcher) Match(payments []*PPDocument, imp *BankImport) []PaymentMatch {
+	res := make([]PaymentMatch, len(payments))
+	used := make([]bool, len(imp.Documents))
+	setMatch := func(i, j int, fuzzy bool) {
+		used[j] = true
+		doc := imp.Documents[j]
+		res[i].Document = doc
+		res[i].Fuzzy = fuzzy
+		res[i].ExecDate = documentKreditDate(doc)
+		if res[i].ExecDate.IsZero() {
+			res[i].Status = PAYMENT_STATUS_PENDING
+		} else {
+			res[i].Status = PAYMENT_STATUS_EXECUTED
+		}
+	}
+
+	for i, p := range payments {
+		res[i].Payment = p
+		for j, doc := range imp.Documents {
+			if !used[j] && m.exactMatch(p, doc) {
+				setMatch(i, j, false)
+				break
+			}
+		}
+	}
+	for i, p := range payments {
+		if res[i].Document != nil {
+			continue
+		}
+		best, best_score, best_dist := -1, -1.0, 0
+		for j, doc := range imp.Documents {
+			if used[j] {
+				continue
+			}
+			score := m.fuzzyScore(p, doc)
+			if score < 0 {
+				continue
+			}
+			dist := dayDistance(p.Date, doc.GetDate())
+			if score > best_score || (score == best_score && dist < best_dist) {
+				best, best_score, best_dist = j, score, dist
+			}
+		}
+		if best >= 0 {
+			setMatch(i, best, true)
+			continue
+		}
+		switch {
+		case !imp.DateFrom.IsZero() && p.Date.Before(imp.DateFrom):
+			res[i].Status = PAYMENT_STATUS_NOT_COVERED
+		case !imp.DateTo.IsZero() && p.Date.Before(imp.DateTo):
+			res[i].Status = PAYMENT_STATUS_MISSING
+		default:
+			res[i].Status = PAYMENT_STATUS_PENDING
+		}
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+package clbnk
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMatchPayments(t *testing.T) {
+	f_cont, err := os.ReadFile("kl_to_1c.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.Unmarshal(f_cont); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	payments := []*PPDocument{
+		//exact match
+		{Num: 2, Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Sum: TEST_DOC2_SUM,
+			Payer:      Party{Account: TEST_DOC2_PAYER_ACC},
+			Receiver:   Party{Account: TEST_DOC2_REC_ACC},
+			PayComment: "Оплата по заказу клиента №9614, НДС не облагается",
+		},
+		//renumbered by bank, date shifted
+		{Num: 15, Date: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), Sum: TEST_DOC1_SUM,
+			Payer:      Party{Account: TEST_DOC1_PAYER_ACC},
+			Receiver:   Party{Inn: TEST_DOC1_REC_INN},
+			PayComment: "Оплата по заказу клиента №9614",
+		},
+		//not in the statement
+		{Num: 3, Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Sum: 500,
+			Payer:    Party{Account: TEST_DOC2_PAYER_ACC},
+			Receiver: Party{Account: TEST_DOC2_REC_ACC},
+		},
+		//after the statement end
+		{Num: 4, Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Sum: 500,
+			Payer:    Party{Account: TEST_DOC2_PAYER_ACC},
+			Receiver: Party{Account: TEST_DOC2_REC_ACC},
+		},
+		//the same sum and accounts, other purpose
+		{Num: 16, Date: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), Sum: TEST_DOC1_SUM,
+			Payer:      Party{Account: TEST_DOC1_PAYER_ACC},
+			Receiver:   Party{Account: TEST_DOC1_REC_ACC},
+			PayComment: "Возврат займа",
+		},
+		//before the statement start
+		{Num: 5, Date: time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC), Sum: 500,
+			Payer:    Party{Account: TEST_DOC2_PAYER_ACC},
+			Receiver: Party{Account: TEST_DOC2_REC_ACC},
+		},
+	}
+	res := NewPaymentMatcher().Match(payments, imp)
+	if len(res) != len(payments) {
+		t.Fatalf("match count, expected %d, got %d", len(payments), len(res))
+	}
+	if r := res[0]; r.Status != PAYMENT_STATUS_EXECUTED || r.Fuzzy || r.Document != imp.Documents[2] ||
+		!r.ExecDate.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("exact match, got %+v", r)
+	}
+	if r := res[1]; r.Status != PAYMENT_STATUS_EXECUTED || !r.Fuzzy || r.Document != imp.Documents[1] {
+		t.Fatalf("fuzzy match, got %+v", r)
+	}
+	if r := res[2]; r.Status != PAYMENT_STATUS_MISSING || r.Document != nil {
+		t.Fatalf("missing payment, got %+v", r)
+	}
+	if r := res[3]; r.Status != PAYMENT_STATUS_PENDING || r.Document != nil {
+		t.Fatalf("pending payment, got %+v", r)
+	}
+	if r := res[4]; r.Status != PAYMENT_STATUS_MISSING {
+		t.Fatalf("payment with other purpose, got %+v", r)
+	}
+	if r := res[5]; r.Status != PAYMENT_STATUS_NOT_COVERED || r.Document != nil {
+		t.Fatalf("payment before the statement, got %+v", r)
+	}
+	if s := PAYMENT_STATUS_EXECUTED.String(); s != "Исполнен" {
+		t.Fatalf("status string, got %s", s)
+	}
+}
@@ -0,0 +1,254 @@
+package clbnk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SWIFT MT940 (Customer Statement Message) import.
+
+// mt940StatementLineExp parses :61: field: value date, entry date, mark,
+// funds code, amount, transaction type, customer and bank references.
+var mt940StatementLineExp = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+(?:,\d*)?)([A-Z][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+
+// mt940BalanceExp parses :60F:, :60M:, :62F:, :62M: fields.
+var mt940BalanceExp = regexp.MustCompile(`^(C|D)(\d{6})([A-Z]{3})(\d+(?:,\d*)?)$`)
+
+// mt940TagExp parses the field tag of a line.
+var mt940TagExp = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+// mt940Fields splits message text into fields, continuation lines
+// are joined to their fields with new lines.
+func mt940Fields(text string) []mt940Field {
+	fields := make([]mt940Field, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " ")
+		if m := mt940TagExp.FindStringSubmatch(line); m != nil {
+			fields = append(fields, mt940Field{tag: m[1], value: m[2]})
+			continue
+		}
+		if line == "" || line == "-" || line == "-}" || strings.HasPrefix(line, "{") {
+			continue
+		}
+		if len(fields) > 0 {
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+	return fields
+}
+
+func parseMT940Date(s string) (time.Time, error) {
+	return time.Parse("060102", s)
+}
+
+func parseMT940Amount(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("MT940: invalid amount %s", s)
+	}
+	return v, nil
+}
+
+// parseMT940Balance returns balance date, currency and signed amount.
+func parseMT940Balance(s string) (time.Time, string, float64, error) {
+	m := mt940BalanceExp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return time.Time{}, "", 0, fmt.Errorf("MT940: invalid balance %s", s)
+	}
+	d, err := parseMT940Date(m[2])
+	if err != nil {
+		return time.Time{}, "", 0, err
+	}
+	sum, err := parseMT940Amount(m[4])
+	if err != nil {
+		return time.Time{}, "", 0, err
+	}
+	if m[1] == "D" {
+		sum = -sum
+	}
+	return d, m[3], sum, nil
+}
+
+// UnmarshalMT940 imports SWIFT MT940 statements. Every statement (:20: field)
+// is added to AccSection with :60F:/:62F: balances, every :61: entry is added
+// to Documents as PPDocument with :86: value as payment purpose.
+// Pages of a multi-page statement (started with :60M: intermediate balance)
+// are joined to one section, intermediate balances are ignored.
+// Data that is not valid UTF-8 is decoded from Windows-1251.
+func (e *BankImport) UnmarshalMT940(data []byte) error {
+	if !utf8.Valid(data) {
+		dec, err := ENCODING_TYPE_WIN.decode(data)
+		if err != nil {
+			return err
+		}
+		data = dec
+	}
+	fields := mt940Fields(string(data))
+	var acc *Account
+	var doc *PPDocument
+	var credit, debit float64
+
+	closeStatement := func() {
+		if acc == nil {
+			return
+		}
+		acc.Debet = credit
+		acc.Kredit = debit
+		e.AccSection = append(e.AccSection, *acc)
+		if e.Account == "" {
+			e.Account = acc.Account
+		}
+		if e.DateFrom.IsZero() || (!acc.DateFrom.IsZero() && acc.DateFrom.Before(e.DateFrom)) {
+			e.DateFrom = acc.DateFrom
+		}
+		if acc.DateTo.After(e.DateTo) {
+			e.DateTo = acc.DateTo
+		}
+		acc = nil
+		doc = nil
+		credit, debit = 0, 0
+	}
+
+	for _, f := range fields {
+		switch f.tag {
+		case "20":
+			closeStatement()
+			acc = &Account{}
+
+		case "25":
+			if acc == nil {
+				return fmt.Errorf("MT940: field :25: before :20:")
+			}
+			//account identification may be prefixed with the bank code
+			v := strings.TrimSpace(f.value)
+			acc.Account = v[strings.LastIndex(v, "/")+1:]
+
+		case "60F", "60M":
+			if acc == nil {
+				return fmt.Errorf("MT940: field :%s: before :20:", f.tag)
+			}
+			d, cur, sum, err := parseMT940Balance(f.value)
+			if err != nil {
+				return err
+			}
+			if f.tag == "60M" {
+				//next page of a multi-page statement continues
+				//the previous statement of the account
+				if n := len(e.AccSection); n > 0 && e.AccSection[n-1].Account == acc.Account {
+					prev := e.AccSection[n-1]
+					acc = &prev
+					credit, debit = acc.Debet, acc.Kredit
+					e.AccSection = e.AccSection[:n-1]
+				}
+				if acc.Currency == "" {
+					acc.Currency = cur
+				}
+				continue
+			}
+			acc.DateFrom = d
+			acc.Currency = cur
+			acc.BalanceStart = sum
+
+		case "62F", "62M":
+			if acc == nil {
+				return fmt.Errorf("MT940: field :%s: before :20:", f.tag)
+			}
+			d, cur, sum, err := parseMT940Balance(f.value)
+			if err != nil {
+				return err
+			}
+			if acc.Currency == "" {
+				acc.Currency = cur
+			}
+			//intermediate balance of a page is not the closing balance
+			if f.tag == "62F" {
+				acc.DateTo = d
+				acc.BalanceEnd = sum
+			}
+
+		case "61":
+			if acc == nil {
+				return fmt.Errorf("MT940: field :61: before :20:")
+			}
+			var err error
+			if doc, err = newMT940Document(f.value, acc.Account); err != nil {
+				return err
+			}
+			doc.Currency = acc.GetCurrency()
+			if doc.DebetDate.IsZero() {
+				debit += doc.Sum
+			} else {
+				credit += doc.Sum
+			}
+			e.Documents = append(e.Documents, doc)
+
+		case "86":
+			if doc != nil {
+				doc.PayComment = strings.TrimSpace(strings.ReplaceAll(f.value, "\n", " "))
+			}
+		}
+	}
+	closeStatement()
+
+	if len(e.AccSection) == 0 {
+		return fmt.Errorf("MT940: %s", ER_INVALID_FILE)
+	}
+	return nil
+}
+
+// newMT940Document creates a document from :61: field value.
+func newMT940Document(value, account string) (*PPDocument, error) {
+	lines := strings.SplitN(value, "\n", 2)
+	m := mt940StatementLineExp.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		return nil, fmt.Errorf("MT940: invalid statement line %s", lines[0])
+	}
+	val_date, err := parseMT940Date(m[1])
+	if err != nil {
+		return nil, err
+	}
+	date := val_date
+	if m[2] != "" {
+		//entry date MMDD, year of the value date
+		if date, err = time.Parse("0601", m[1][:2]+m[2][:2]); err != nil {
+			return nil, err
+		}
+		day, err := strconv.Atoi(m[2][2:])
+		if err != nil {
+			return nil, err
+		}
+		date = time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, time.UTC)
+		//entry in the next year
+		if date.Sub(val_date) < -180*24*time.Hour {
+			date = date.AddDate(1, 0, 0)
+		} else if date.Sub(val_date) > 180*24*time.Hour {
+			date = date.AddDate(-1, 0, 0)
+		}
+	}
+	sum, err := parseMT940Amount(m[5])
+	if err != nil {
+		return nil, err
+	}
+	doc := &PPDocument{Date: date, Sum: sum}
+	if n, err := strconv.Atoi(strings.TrimSpace(m[7])); err == nil {
+		doc.Num = n
+	}
+	//reversal of credit is a debit, reversal of debit is a credit
+	if m[3] == "C" || m[3] == "RD" {
+		doc.DebetDate = date
+		doc.Receiver.Account = account
+	} else {
+		doc.KreditDate = date
+		doc.Payer.Account = account
+	}
+	return doc, nil
+}
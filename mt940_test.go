@@ -0,0 +1,93 @@
+package clbnk
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const TEST_MT940_ACC = "40702840000000001234"
+
+func TestImportMT940(t *testing.T) {
+	f_cont, err := os.ReadFile("mt940.txt")
+	if err != nil {
+		panic(err)
+	}
+	imp := NewBankImport()
+	if err := imp.UnmarshalMT940(f_cont); err != nil {
+		t.Fatalf("UnmarshalMT940 failed: %v", err)
+	}
+	if imp.Account != TEST_MT940_ACC {
+		t.Fatalf("account, expected %s, got %s", TEST_MT940_ACC, imp.Account)
+	}
+	if len(imp.AccSection) != 1 {
+		t.Fatalf("account section count, expected 1, got %d", len(imp.AccSection))
+	}
+	acc := imp.AccSection[0]
+	if acc.BalanceStart != 15000 || acc.BalanceEnd != 16500.5 || acc.Debet != 2500.5 || acc.Kredit != 1000 {
+		t.Fatalf("account section values, got %+v", acc)
+	}
+	if acc.Currency != CURRENCY_USD {
+		t.Fatalf("account section currency, expected %s, got %s", CURRENCY_USD, acc.Currency)
+	}
+	if !acc.DateTo.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("account section date to, got %v", acc.DateTo)
+	}
+	if len(imp.Documents) != 2 {
+		t.Fatalf("document count, expected 2, got %d", len(imp.Documents))
+	}
+	for _, d := range imp.Documents {
+		if c := d.GetCurrency(); c != CURRENCY_USD {
+			t.Fatalf("document currency, expected %s, got %s", CURRENCY_USD, c)
+		}
+	}
+	in := imp.Incoming("")
+	if len(in) != 1 || in[0].GetNum() != 125 || in[0].GetSum() != 2500.5 {
+		t.Fatalf("incoming documents, got %+v", in)
+	}
+	if p := in[0].GetPurpose(); p != "Payment for invoice 125 dated 05.01.2024 VAT not applicable" {
+		t.Fatalf("incoming document purpose, got %s", p)
+	}
+	out := imp.Outgoing("")
+	if len(out) != 1 || out[0].GetSum() != 1000 || !out[0].GetDate().Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("outgoing documents, got %+v", out)
+	}
+
+	testJSONRoundTrip(t, imp)
+}
+
+func TestImportMT940Pages(t *testing.T) {
+	data := strings.Join([]string{":20:STMT240110",
+		":25:BANKRUMM/" + TEST_MT940_ACC,
+		":28C:5/1",
+		":60F:C240109USD15000,00",
+		":61:2401090109C2500,50NTRF125//BR24010900001",
+		":62M:C240109USD17500,50",
+		"-",
+		":20:STMT240110",
+		":25:BANKRUMM/" + TEST_MT940_ACC,
+		":28C:5/2",
+		":60M:C240109USD17500,50",
+		":61:2401100110D1000,NTRFNONREF//BR24011000002",
+		":62F:C240110USD16500,50",
+		"-",
+	}, "\n")
+	imp := NewBankImport()
+	if err := imp.UnmarshalMT940([]byte(data)); err != nil {
+		t.Fatalf("UnmarshalMT940 failed: %v", err)
+	}
+	if len(imp.AccSection) != 1 {
+		t.Fatalf("account section count, expected 1, got %d", len(imp.AccSection))
+	}
+	acc := imp.AccSection[0]
+	if acc.BalanceStart != 15000 || acc.BalanceEnd != 16500.5 || acc.Debet != 2500.5 || acc.Kredit != 1000 {
+		t.Fatalf("account section values, got %+v", acc)
+	}
+	if !acc.DateFrom.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) || !acc.DateTo.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("account section dates, got %v - %v", acc.DateFrom, acc.DateTo)
+	}
+	if len(imp.Documents) != 2 {
+		t.Fatalf("document count, expected 2, got %d", len(imp.Documents))
+	}
+}
@@ -0,0 +1,287 @@
+package clbnk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Numerator assigns document numbers per payer account per year.
+type Numerator interface {
+	// Next returns the next free number and marks it as used.
+	Next(account string, year int) (int, error)
+	// Use marks the number as used, error is returned if it is already used.
+	Use(account string, year int, num int) error
+}
+
+// NumberedDocument is implemented by documents numbered by Numerator.
+type NumberedDocument interface {
+	Document
+	SetNum(num int)
+}
+
+// SetNum sets the document number.
+func (d *PPDocument) SetNum(num int) {
+	d.Num = num
+}
+
+// numeratorKey returns account/year key.
+func numeratorKey(account string, year int) string {
+	return account + "/" + strconv.Itoa(year)
+}
+
+// MemoryNumerator keeps used numbers in memory.
+type MemoryNumerator struct {
+	mx   sync.Mutex
+	used map[string]map[int]struct{}
+}
+
+// NewMemoryNumerator creates an empty numerator.
+func NewMemoryNumerator() *MemoryNumerator {
+	return &MemoryNumerator{used: make(map[string]map[int]struct{})}
+}
+
+// next returns the number following the greatest used number.
+func (n *MemoryNumerator) next(key string) int {
+	max := 0
+	for num := range n.used[key] {
+		if num > max {
+			max = num
+		}
+	}
+	return max + 1
+}
+
+func (n *MemoryNumerator) use(key string, num int) error {
+	if num <= 0 {
+		return fmt.Errorf("invalid number %d", num)
+	}
+	if _, ok := n.used[key][num]; ok {
+		return fmt.Errorf("number %d is already used for %s", num, key)
+	}
+	if n.used[key] == nil {
+		n.used[key] = make(map[int]struct{})
+	}
+	n.used[key][num] = struct{}{}
+	return nil
+}
+
+// Next implements Numerator.
+func (n *MemoryNumerator) Next(account string, year int) (int, error) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	key := numeratorKey(account, year)
+	num := n.next(key)
+	return num, n.use(key, num)
+}
+
+// Use implements Numerator.
+func (n *MemoryNumerator) Use(account string, year int, num int) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	return n.use(numeratorKey(account, year), num)
+}
+
+// FileNumerator keeps used numbers in a JSON file,
+// the file is rewritten after every change. Next and Use read the file again
+// under a lock file (the file name with .lock suffix), so processes
+// sharing the file do not get the same numbers.
+type FileNumerator struct {
+	MemoryNumerator
+	fileName string
+}
+
+// fileNumeratorLockWait is the time to wait for the lock file of another process.
+var fileNumeratorLockWait = 10 * time.Second
+
+// NewFileNumerator creates a numerator with used numbers
+// loaded from the file. Absent file means no used numbers.
+func NewFileNumerator(fileName string) (*FileNumerator, error) {
+	n := &FileNumerator{MemoryNumerator: MemoryNumerator{used: make(map[string]map[int]struct{})},
+		fileName: fileName,
+	}
+	if err := n.load(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// load replaces used numbers with numbers of the file.
+func (n *FileNumerator) load() error {
+	n.used = make(map[string]map[int]struct{})
+	data, err := os.ReadFile(n.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	used := make(map[string][]int)
+	if err := json.Unmarshal(data, &used); err != nil {
+		return fmt.Errorf("%s: %v", n.fileName, err)
+	}
+	for key, nums := range used {
+		for _, num := range nums {
+			if err := n.use(key, num); err != nil {
+				return fmt.Errorf("%s: %v", n.fileName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// lock creates the lock file, waiting while another process holds it.
+// The returned function removes the lock file.
+func (n *FileNumerator) lock() (func(), error) {
+	lock_name := n.fileName + ".lock"
+	deadline := time.Now().Add(fileNumeratorLockWait)
+	for {
+		f, err := os.OpenFile(lock_name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock_name) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked, remove the lock file if no other process uses it", lock_name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// update reads the file, changes used numbers and saves them under the lock.
+func (n *FileNumerator) update(change func() error) error {
+	unlock, err := n.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := n.load(); err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return n.save()
+}
+
+// save writes used numbers to a temporary file and renames it.
+func (n *FileNumerator) save() error {
+	used := make(map[string][]int, len(n.used))
+	for key, nums := range n.used {
+		for num := range nums {
+			used[key] = append(used[key], num)
+		}
+		sort.Ints(used[key])
+	}
+	data, err := json.MarshalIndent(used, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(n.fileName), filepath.Base(n.fileName)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), n.fileName)
+}
+
+// Next implements Numerator.
+func (n *FileNumerator) Next(account string, year int) (int, error) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	key := numeratorKey(account, year)
+	num := 0
+	err := n.update(func() error {
+		num = n.next(key)
+		return n.use(key, num)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return num, nil
+}
+
+// Use implements Numerator.
+func (n *FileNumerator) Use(account string, year int, num int) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	return n.update(func() error {
+		return n.use(numeratorKey(account, year), num)
+	})
+}
+
+// AssignNumbers numbers documents without numbers by the numerator,
+// numbers of numbered documents are marked as used first, so the result
+// does not depend on the document order. It must be called once for new documents.
+// Empty payer fields are filled from the payer profile first,
+// as numbers are kept per payer account.
+// If Next fails, documents numbered by this call get zero numbers back,
+// numbers already taken from the numerator are not returned to it.
+func (e *BankExport) AssignNumbers(n Numerator) error {
+	e.applyProfile()
+	errs := make([]error, 0)
+	docs := make([]NumberedDocument, 0)
+	for _, doc := range e.Documents {
+		d, ok := doc.(NumberedDocument)
+		if !ok {
+			continue
+		}
+		if d.GetNum() == 0 {
+			docs = append(docs, d)
+			continue
+		}
+		if err := n.Use(d.GetPayer().Account, d.GetDate().Year(), d.GetNum()); err != nil {
+			errs = append(errs, fmt.Errorf("document %d: %v", d.GetNum(), err))
+		}
+	}
+	for i, d := range docs {
+		num, err := n.Next(d.GetPayer().Account, d.GetDate().Year())
+		if err != nil {
+			for _, d := range docs[:i] {
+				d.SetNum(0)
+			}
+			return err
+		}
+		d.SetNum(num)
+	}
+	return errors.Join(errs...)
+}
+
+// checkDuplicateNumbers returns an error if documents of one payer account
+// have the same number within a year.
+func checkDuplicateNumbers(documents []BankExportDocument) error {
+	nums := make(map[string]struct{})
+	dupl := make([]string, 0)
+	for _, d := range documents {
+		if d.GetNum() == 0 {
+			continue
+		}
+		key := numeratorKey(d.GetPayer().Account, d.GetDate().Year()) + "/" + strconv.Itoa(d.GetNum())
+		if _, ok := nums[key]; ok {
+			dupl = append(dupl, key)
+		}
+		nums[key] = struct{}{}
+	}
+	if len(dupl) > 0 {
+		return fmt.Errorf("duplicate document numbers (account/year/number): %s", strings.Join(dupl, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package clbnk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	TEST_NUM_ACC1 = "40702810000000000001"
+	TEST_NUM_ACC2 = "40702810000000000003"
+)
+
+func TestMemoryNumerator(t *testing.T) {
+	n := NewMemoryNumerator()
+	for i := 1; i <= 3; i++ {
+		if num, err := n.Next(TEST_NUM_ACC1, 2024); err != nil || num != i {
+			t.Fatalf("Next, expected %d, got %d, %v", i, num, err)
+		}
+	}
+	if num, _ := n.Next(TEST_NUM_ACC2, 2024); num != 1 {
+		t.Fatalf("Next for another account, expected 1, got %d", num)
+	}
+	if num, _ := n.Next(TEST_NUM_ACC1, 2025); num != 1 {
+		t.Fatalf("Next for another year, expected 1, got %d", num)
+	}
+	if err := n.Use(TEST_NUM_ACC1, 2024, 2); err == nil {
+		t.Fatal("Use of used number must fail")
+	}
+	if err := n.Use(TEST_NUM_ACC1, 2024, 10); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if num, _ := n.Next(TEST_NUM_ACC1, 2024); num != 11 {
+		t.Fatalf("Next after Use, expected 11, got %d", num)
+	}
+}
+
+func TestFileNumerator(t *testing.T) {
+	file_name := filepath.Join(t.TempDir(), "numbers.json")
+	n, err := NewFileNumerator(file_name)
+	if err != nil {
+		t.Fatalf("NewFileNumerator failed: %v", err)
+	}
+	if num, err := n.Next(TEST_NUM_ACC1, 2024); err != nil || num != 1 {
+		t.Fatalf("Next, expected 1, got %d, %v", num, err)
+	}
+	if err := n.Use(TEST_NUM_ACC1, 2024, 5); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	n, err = NewFileNumerator(file_name)
+	if err != nil {
+		t.Fatalf("NewFileNumerator of existing file failed: %v", err)
+	}
+	if err := n.Use(TEST_NUM_ACC1, 2024, 1); err == nil {
+		t.Fatal("Use of number saved to file must fail")
+	}
+	if num, _ := n.Next(TEST_NUM_ACC1, 2024); num != 6 {
+		t.Fatalf("Next after reload, expected 6, got %d", num)
+	}
+
+	//numerators of the same file do not repeat numbers of each other
+	n2, err := NewFileNumerator(file_name)
+	if err != nil {
+		t.Fatalf("NewFileNumerator failed: %v", err)
+	}
+	if num, _ := n.Next(TEST_NUM_ACC1, 2024); num != 7 {
+		t.Fatalf("Next, expected 7, got %d", num)
+	}
+	if num, _ := n2.Next(TEST_NUM_ACC1, 2024); num != 8 {
+		t.Fatalf("Next of another numerator, expected 8, got %d", num)
+	}
+	if err := n.Use(TEST_NUM_ACC1, 2024, 8); err == nil {
+		t.Fatal("Use of number of another numerator must fail")
+	}
+
+	//lock file of another process
+	lock_wait := fileNumeratorLockWait
+	fileNumeratorLockWait = 100 * time.Millisecond
+	defer func() { fileNumeratorLockWait = lock_wait }()
+	if err := os.WriteFile(file_name+".lock", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Next(TEST_NUM_ACC1, 2024); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("Next with locked file must fail, got %v", err)
+	}
+	os.Remove(file_name + ".lock")
+	if num, err := n.Next(TEST_NUM_ACC1, 2024); err != nil || num != 9 {
+		t.Fatalf("Next after unlock, expected 9, got %d, %v", num, err)
+	}
+}
+
+func TestExportNumbers(t *testing.T) {
+	newDoc := func(num int, account string, date time.Time) *PPDocument {
+		return &PPDocument{Num: num, Date: date, Sum: 100, Order: 5, PayComment: "За товары",
+			Payer: Party{Name: "ООО Тест", Inn: "1234567891", Account: account,
+				Bank: BankInfo{Bik: "044525225", Account: "30101810400000000225"},
+			},
+			Receiver: Party{Name: `ИП Иванов А.А.`, Inn: "111122223344", Account: "40802810000000000002",
+				Bank: BankInfo{Bik: "044525225", Account: "30101810400000000225"},
+			},
+		}
+	}
+	d2024 := time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)
+	d2025 := time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)
+
+	//the same number of another account or year is not a duplicate
+	exp := NewBankExport([]BankExportDocument{newDoc(1, TEST_NUM_ACC1, d2024),
+		newDoc(1, TEST_NUM_ACC2, d2024),
+		newDoc(1, TEST_NUM_ACC1, d2025),
+	})
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	exp.Documents = append(exp.Documents, newDoc(1, TEST_NUM_ACC1, d2024))
+	if _, err := exp.Marshal(); err == nil || !strings.Contains(err.Error(), TEST_NUM_ACC1+"/2024/1") {
+		t.Fatalf("Marshal of duplicate numbers must fail, got %v", err)
+	}
+	if err := exp.Validate(); err == nil {
+		t.Fatal("Validate of duplicate numbers must fail")
+	}
+
+	n := NewMemoryNumerator()
+	docs := []*PPDocument{newDoc(0, TEST_NUM_ACC1, d2024), newDoc(2, TEST_NUM_ACC1, d2024), newDoc(0, TEST_NUM_ACC1, d2024)}
+	exp = NewBankExport([]BankExportDocument{docs[0], docs[1], docs[2]})
+	if err := exp.AssignNumbers(n); err != nil {
+		t.Fatalf("AssignNumbers failed: %v", err)
+	}
+	if docs[0].Num != 3 || docs[1].Num != 2 || docs[2].Num != 4 {
+		t.Fatalf("assigned numbers, expected 3, 2, 4, got %d, %d, %d", docs[0].Num, docs[1].Num, docs[2].Num)
+	}
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal of numbered documents failed: %v", err)
+	}
+	//numbers of the next export are not repeated
+	exp = NewBankExport([]BankExportDocument{newDoc(3, TEST_NUM_ACC1, d2024)})
+	if err := exp.AssignNumbers(n); err == nil {
+		t.Fatal("AssignNumbers with used number must fail")
+	}
+
+	//given numbers are reserved before numbering of other documents
+	n = NewMemoryNumerator()
+	docs = []*PPDocument{newDoc(0, TEST_NUM_ACC1, d2024), newDoc(1, TEST_NUM_ACC1, d2024)}
+	exp = NewBankExport([]BankExportDocument{docs[0], docs[1]})
+	if err := exp.AssignNumbers(n); err != nil {
+		t.Fatalf("AssignNumbers failed: %v", err)
+	}
+	if docs[0].Num != 2 || docs[1].Num != 1 {
+		t.Fatalf("assigned numbers, expected 2, 1, got %d, %d", docs[0].Num, docs[1].Num)
+	}
+
+	//numbers are kept by the profile account of documents without payer account
+	payer, err := NewPayerProfile(newDoc(0, TEST_NUM_ACC2, d2024).Payer)
+	if err != nil {
+		t.Fatalf("NewPayerProfile failed: %v", err)
+	}
+	n = NewMemoryNumerator()
+	docs = []*PPDocument{newDoc(0, "", d2024), newDoc(0, TEST_NUM_ACC1, d2024)}
+	exp = NewBankExport([]BankExportDocument{docs[0], docs[1]})
+	exp.Payer = payer
+	if err := exp.AssignNumbers(n); err != nil {
+		t.Fatalf("AssignNumbers with payer profile failed: %v", err)
+	}
+	if docs[0].Payer.Account != TEST_NUM_ACC2 || docs[0].Num != 1 || docs[1].Num != 1 {
+		t.Fatalf("numbers with payer profile, expected 1, 1 of %s, got %d, %d of %s", TEST_NUM_ACC2, docs[0].Num, docs[1].Num, docs[0].Payer.Account)
+	}
+	if err := n.Use(TEST_NUM_ACC2, 2024, 1); err == nil {
+		t.Fatal("number of profile account must be used")
+	}
+
+	//documents numbered before an error get zero numbers back
+	docs = []*PPDocument{newDoc(0, TEST_NUM_ACC1, d2024), newDoc(0, TEST_NUM_ACC1, d2024)}
+	exp = NewBankExport([]BankExportDocument{docs[0], docs[1]})
+	if err := exp.AssignNumbers(&failNumerator{MemoryNumerator: NewMemoryNumerator(), left: 1}); err == nil {
+		t.Fatal("AssignNumbers must fail if Next fails")
+	}
+	if docs[0].Num != 0 || docs[1].Num != 0 {
+		t.Fatalf("numbers after failure, expected 0, 0, got %d, %d", docs[0].Num, docs[1].Num)
+	}
+}
+
+// failNumerator fails Next after left numbers.
+type failNumerator struct {
+	*MemoryNumerator
+	left int
+}
+
+func (n *failNumerator) Next(account string, year int) (int, error) {
+	if n.left == 0 {
+		return 0, fmt.Errorf("numerator failed")
+	}
+	n.left--
+	return n.MemoryNumerator.Next(account, year)
+}
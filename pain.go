@@ -0,0 +1,335 @@
+package clbnk
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// ISO 20022 pain.001 (CustomerCreditTransferInitiation) export.
+
+const (
+	PAIN_001_NS       = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	PAIN_PMT_MTD      = "TRF"
+	PAIN_CLR_SYS_RU   = "RUCBC" // Bank of Russia clearing system, member id is BIK
+	PAIN_TAX_ID_CODE  = "TXID"
+	PAIN_DEF_CURRENCY = CURRENCY_RUB // if not derived from accounts
+	PAIN_ID_MAX_LEN   = 35           // Max35Text of MsgId and PmtInfId
+)
+
+type painDocument struct {
+	XMLName xml.Name `xml:"Document"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Initn   struct {
+		GrpHdr struct {
+			MsgId    string `xml:"MsgId"`
+			CreDtTm  string `xml:"CreDtTm"`
+			NbOfTxs  int    `xml:"NbOfTxs"`
+			CtrlSum  string `xml:"CtrlSum"`
+			InitgPty struct {
+				Nm string `xml:"Nm"`
+			} `xml:"InitgPty"`
+		} `xml:"GrpHdr"`
+		PmtInf []*painPaymentInfo `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type painPaymentInfo struct {
+	PmtInfId    string           `xml:"PmtInfId"`
+	PmtMtd      string           `xml:"PmtMtd"`
+	NbOfTxs     int              `xml:"NbOfTxs"`
+	CtrlSum     string           `xml:"CtrlSum"`
+	ReqdExctnDt string           `xml:"ReqdExctnDt"`
+	Dbtr        painParty        `xml:"Dbtr"`
+	DbtrAcct    painAccount      `xml:"DbtrAcct"`
+	DbtrAgt     painAgent        `xml:"DbtrAgt"`
+	DbtrAgtAcct *painAccount     `xml:"DbtrAgtAcct,omitempty"`
+	CdtTrfTxInf []painTxInfo     `xml:"CdtTrfTxInf"`
+	key         painPaymentGroup `xml:"-"`
+	sum         float64          `xml:"-"`
+}
+
+// painPaymentGroup groups transactions of PmtInf: payer account and execution date.
+type painPaymentGroup struct {
+	account string
+	date    string
+}
+
+type painParty struct {
+	Nm string       `xml:"Nm"`
+	Id *painPartyId `xml:"Id,omitempty"`
+}
+
+type painPartyId struct {
+	OrgId struct {
+		Othr struct {
+			Id      string `xml:"Id"`
+			SchmeNm struct {
+				Cd string `xml:"Cd"`
+			} `xml:"SchmeNm"`
+		} `xml:"Othr"`
+	} `xml:"OrgId"`
+}
+
+type painAccount struct {
+	Id struct {
+		Othr struct {
+			Id string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+	Ccy string `xml:"Ccy,omitempty"`
+}
+
+type painClrSysMmbId struct {
+	ClrSysId struct {
+		Cd string `xml:"Cd"`
+	} `xml:"ClrSysId"`
+	MmbId string `xml:"MmbId"`
+}
+
+type painPstlAdr struct {
+	TwnNm string `xml:"TwnNm"`
+}
+
+type painAgent struct {
+	FinInstnId struct {
+		ClrSysMmbId *painClrSysMmbId `xml:"ClrSysMmbId,omitempty"`
+		Nm          string           `xml:"Nm,omitempty"`
+		PstlAdr     *painPstlAdr     `xml:"PstlAdr,omitempty"`
+	} `xml:"FinInstnId"`
+}
+
+type painAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type painTxInfo struct {
+	PmtId struct {
+		InstrId    string `xml:"InstrId"`
+		EndToEndId string `xml:"EndToEndId"`
+	} `xml:"PmtId"`
+	PmtTpInf *painPmtTpInf `xml:"PmtTpInf,omitempty"`
+	Amt      struct {
+		InstdAmt painAmount `xml:"InstdAmt"`
+	} `xml:"Amt"`
+	CdtrAgt     painAgent    `xml:"CdtrAgt"`
+	CdtrAgtAcct *painAccount `xml:"CdtrAgtAcct,omitempty"`
+	Cdtr        painParty    `xml:"Cdtr"`
+	CdtrAcct    painAccount  `xml:"CdtrAcct"`
+	Tax         *painTax     `xml:"Tax,omitempty"`
+	RmtInf      *painRmtInf  `xml:"RmtInf,omitempty"`
+}
+
+type painPmtTpInf struct {
+	InstrPrty string `xml:"InstrPrty"`
+}
+
+type painRmtInf struct {
+	Ustrd []string `xml:"Ustrd"`
+}
+
+type painTaxParty struct {
+	TaxId  string `xml:"TaxId,omitempty"`
+	RegnId string `xml:"RegnId,omitempty"`
+	TaxTp  string `xml:"TaxTp,omitempty"`
+}
+
+// painTax holds budget payment fields.
+type painTax struct {
+	Cdtr     painTaxParty `xml:"Cdtr"`
+	Dbtr     painTaxParty `xml:"Dbtr"`
+	AdmstnZn string       `xml:"AdmstnZn,omitempty"` // ОКТМО
+	RefNb    string       `xml:"RefNb,omitempty"`    // УИН
+	Dt       string       `xml:"Dt,omitempty"`       // ПоказательДаты
+	Rcrd     struct {
+		Tp       string `xml:"Tp,omitempty"`       // КБК
+		Ctgy     string `xml:"Ctgy,omitempty"`     // ПоказательОснования
+		CtgyDtls string `xml:"CtgyDtls,omitempty"` // ПоказательТипа
+		CertId   string `xml:"CertId,omitempty"`   // ПоказательНомера
+		AddtlInf string `xml:"AddtlInf,omitempty"` // ПоказательПериода
+	} `xml:"Rcrd"`
+}
+
+func newPainParty(p *Party) painParty {
+	res := painParty{Nm: p.Name}
+	if p.Inn != "" {
+		res.Id = &painPartyId{}
+		res.Id.OrgId.Othr.Id = p.Inn
+		res.Id.OrgId.Othr.SchmeNm.Cd = PAIN_TAX_ID_CODE
+	}
+	return res
+}
+
+func newPainAccount(account, currency string) painAccount {
+	res := painAccount{Ccy: currency}
+	res.Id.Othr.Id = account
+	return res
+}
+
+func newPainAgent(b *BankInfo) painAgent {
+	res := painAgent{}
+	if b.Bik != "" {
+		res.FinInstnId.ClrSysMmbId = &painClrSysMmbId{MmbId: b.Bik}
+		res.FinInstnId.ClrSysMmbId.ClrSysId.Cd = PAIN_CLR_SYS_RU
+	}
+	res.FinInstnId.Nm = b.Name
+	if b.Place != "" {
+		res.FinInstnId.PstlAdr = &painPstlAdr{TwnNm: b.Place}
+	}
+	return res
+}
+
+// newPainTax returns budget fields of the document, nil for ordinary payments.
+func newPainTax(d *PPDocument) *painTax {
+	if !d.IsBudget() {
+		return nil
+	}
+	tax := &painTax{AdmstnZn: d.OKATOValue, RefNb: d.Code}
+	tax.Cdtr.TaxId = d.Receiver.Inn
+	tax.Cdtr.RegnId = d.Receiver.Kpp
+	tax.Dbtr.TaxId = d.Payer.Inn
+	tax.Dbtr.RegnId = d.Payer.Kpp
+	tax.Dbtr.TaxTp = d.CompilerStatus
+	if t, err := time.Parse("02.01.2006", d.DateValue); err == nil {
+		tax.Dt = t.Format("2006-01-02")
+	}
+	tax.Rcrd.Tp = d.KBKValue
+	tax.Rcrd.Ctgy = d.OsnovanieValue
+	tax.Rcrd.CtgyDtls = d.TipValue
+	tax.Rcrd.CertId = d.NomerValue
+	tax.Rcrd.AddtlInf = d.PeriodValue
+	return tax
+}
+
+// newPainMsgId returns a unique message id: time with random bytes.
+func newPainMsgId(t time.Time) (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return t.Format("20060102150405") + "-" + hex.EncodeToString(b), nil
+}
+
+// painPmtInfId returns PmtInfId of the n-th payment information: message id
+// with the number, the message id is cut to fit the length limit.
+func painPmtInfId(msgId string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	if r := []rune(msgId); len(r)+len(suffix) > PAIN_ID_MAX_LEN {
+		msgId = string(r[:PAIN_ID_MAX_LEN-len(suffix)])
+	}
+	return msgId + suffix
+}
+
+// MarshalPain001 exports all documents as ISO 20022 pain.001 XML.
+// Documents are grouped to PmtInf elements by payer account and date.
+// Only PPDocument documents are supported. Message id is MsgId
+// or a unique value generated for every call if it is empty,
+// MsgId longer than 35 characters is an error.
+func (e *BankExport) MarshalPain001() ([]byte, error) {
+	if len(e.Documents) == 0 {
+		return nil, fmt.Errorf("no documents")
+	}
+	if utf8.RuneCountInString(e.MsgId) > PAIN_ID_MAX_LEN {
+		return nil, fmt.Errorf("pain.001: message id %q is longer than %d characters", e.MsgId, PAIN_ID_MAX_LEN)
+	}
+	if err := e.beforeMarshal(); err != nil {
+		return nil, err
+	}
+
+	doc := painDocument{Xmlns: PAIN_001_NS}
+	cr_date := e.CreateDate
+	if cr_date.IsZero() {
+		cr_date = time.Now()
+	}
+	cr_time := e.CreateTime
+	if cr_time == "" {
+		cr_time = cr_date.Format("15:04:05")
+	}
+	hdr := &doc.Initn.GrpHdr
+	hdr.CreDtTm = cr_date.Format("2006-01-02") + "T" + cr_time
+	hdr.MsgId = e.MsgId
+	if hdr.MsgId == "" {
+		var err error
+		if hdr.MsgId, err = newPainMsgId(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	var total float64
+	groups := make(map[painPaymentGroup]*painPaymentInfo)
+	for i, exp_doc := range e.Documents {
+		d, ok := exp_doc.(*PPDocument)
+		if !ok {
+			return nil, fmt.Errorf("pain.001: document %d of type %s is not supported", i, exp_doc.GetType())
+		}
+		currency := d.GetCurrency()
+		if currency == "" {
+			currency = PAIN_DEF_CURRENCY
+		}
+		key := painPaymentGroup{account: d.Payer.Account, date: d.Date.Format("2006-01-02")}
+		pmt, ok := groups[key]
+		if !ok {
+			pmt = &painPaymentInfo{PmtInfId: painPmtInfId(hdr.MsgId, len(groups)+1),
+				PmtMtd:      PAIN_PMT_MTD,
+				ReqdExctnDt: key.date,
+				Dbtr:        newPainParty(&d.Payer),
+				DbtrAcct:    newPainAccount(d.Payer.Account, currency),
+				DbtrAgt:     newPainAgent(&d.Payer.Bank),
+				key:         key,
+			}
+			if d.Payer.Bank.Account != "" {
+				acc := newPainAccount(d.Payer.Bank.Account, "")
+				pmt.DbtrAgtAcct = &acc
+			}
+			groups[key] = pmt
+			doc.Initn.PmtInf = append(doc.Initn.PmtInf, pmt)
+			if hdr.InitgPty.Nm == "" {
+				hdr.InitgPty.Nm = d.Payer.Name
+			}
+		}
+
+		tx := painTxInfo{CdtrAgt: newPainAgent(&d.Receiver.Bank),
+			Cdtr:     newPainParty(&d.Receiver),
+			CdtrAcct: newPainAccount(d.Receiver.Account, ""),
+			Tax:      newPainTax(d),
+		}
+		tx.PmtId.InstrId = fmt.Sprintf("%d", d.Num)
+		tx.PmtId.EndToEndId = tx.PmtId.InstrId
+		if d.PayType == PAY_TYPE_URGENT {
+			tx.PmtTpInf = &painPmtTpInf{InstrPrty: "HIGH"}
+		}
+		tx.Amt.InstdAmt = painAmount{Ccy: currency, Value: fmt.Sprintf("%.2f", d.Sum)}
+		if d.Receiver.Bank.Account != "" {
+			acc := newPainAccount(d.Receiver.Bank.Account, "")
+			tx.CdtrAgtAcct = &acc
+		}
+		if d.PayComment != "" {
+			//Ustrd is limited to 140 characters
+			lines, err := WrapLines(d.PayComment, 2, 140)
+			if err != nil {
+				return nil, fmt.Errorf("pain.001: document %d: %v", i, err)
+			}
+			tx.RmtInf = &painRmtInf{Ustrd: lines}
+		}
+		pmt.CdtTrfTxInf = append(pmt.CdtTrfTxInf, tx)
+		pmt.NbOfTxs++
+		pmt.sum += d.Sum
+		total += d.Sum
+	}
+	for _, pmt := range doc.Initn.PmtInf {
+		pmt.CtrlSum = fmt.Sprintf("%.2f", pmt.sum)
+	}
+	hdr.NbOfTxs = len(e.Documents)
+	hdr.CtrlSum = fmt.Sprintf("%.2f", total)
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
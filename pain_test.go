@@ -0,0 +1,177 @@
+package clbnk
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// xmlPaths returns text values by element paths
+// and child element names of every element instance by paths.
+func xmlPaths(t *testing.T, data []byte) (map[string][]string, map[string][][]string) {
+	values := make(map[string][]string)
+	children := make(map[string][][]string)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	path := make([]string, 0)
+	elems := [][]string{{}}
+	var text string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch tk := tok.(type) {
+		case xml.StartElement:
+			elems[len(elems)-1] = append(elems[len(elems)-1], tk.Name.Local)
+			elems = append(elems, []string{})
+			path = append(path, tk.Name.Local)
+			text = ""
+		case xml.CharData:
+			text += strings.TrimSpace(string(tk))
+		case xml.EndElement:
+			p := strings.Join(path, "/")
+			if text != "" {
+				values[p] = append(values[p], text)
+			}
+			children[p] = append(children[p], elems[len(elems)-1])
+			elems = elems[:len(elems)-1]
+			text = ""
+			path = path[:len(path)-1]
+		}
+	}
+	if len(path) != 0 {
+		t.Fatalf("unbalanced XML")
+	}
+	return values, children
+}
+
+// checkOrder checks that child elements follow the schema sequence.
+func checkOrder(t *testing.T, instances [][]string, sequence []string) {
+	if len(instances) == 0 {
+		t.Fatalf("no elements with sequence %v", sequence)
+	}
+	pos := make(map[string]int)
+	for i, s := range sequence {
+		pos[s] = i
+	}
+	for _, elems := range instances {
+		last := -1
+		for _, e := range elems {
+			p, ok := pos[e]
+			if !ok {
+				t.Fatalf("element %s is not allowed in sequence %v", e, sequence)
+			}
+			if p < last {
+				t.Fatalf("element %s is out of schema order %v", e, sequence)
+			}
+			last = p
+		}
+	}
+}
+
+func TestExportPain001(t *testing.T) {
+	payer := Party{Name: `ООО "Рога и Копыта"`,
+		Inn:     "1234567891",
+		Kpp:     "770401001",
+		Account: "40702810000000077777",
+		Bank:    BankInfo{Name: "ПАО Банк", Place: "г. Москва", Bik: "044525411", Account: "30101810145250000411"},
+	}
+	date := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	exp := NewBankExport([]BankExportDocument{&PPDocument{Num: 1,
+		Date:       date,
+		Sum:        175000,
+		Payer:      payer,
+		Receiver:   Party{Name: "ИП Иванов А.А.", Inn: "111122223344", Account: "40802810000000000001", Bank: BankInfo{Bik: "047102651"}},
+		PayComment: "За товары, по счету №125 на сумму 175000-00",
+	},
+		&PPDocument{Num: 2,
+			Date:           date,
+			Sum:            1000.5,
+			Payer:          payer,
+			Receiver:       Party{Name: "УФК по г. Москве (ИФНС России № 4)", Inn: "7704000000", Kpp: "770401001", Account: "03100643000000017300", Bank: BankInfo{Bik: "004525988"}},
+			PayComment:     "Налог на прибыль",
+			CompilerStatus: "01",
+			KBKValue:       "18210101011011000110",
+			OKATOValue:     "45382000",
+			OsnovanieValue: "ТП",
+			PeriodValue:    "МС.05.2024",
+			NomerValue:     "0",
+			DateValue:      "0",
+		},
+	})
+	b, err := exp.MarshalPain001()
+	if err != nil {
+		t.Fatalf("MarshalPain001() failed: %v", err)
+	}
+	values, children := xmlPaths(t, b)
+
+	const root = "Document/CstmrCdtTrfInitn"
+	checkOrder(t, children["Document"], []string{"CstmrCdtTrfInitn"})
+	checkOrder(t, children[root], []string{"GrpHdr", "PmtInf"})
+	checkOrder(t, children[root+"/GrpHdr"], []string{"MsgId", "CreDtTm", "Authstn", "NbOfTxs", "CtrlSum", "InitgPty"})
+	checkOrder(t, children[root+"/PmtInf"], []string{"PmtInfId", "PmtMtd", "BtchBookg", "NbOfTxs", "CtrlSum", "PmtTpInf",
+		"ReqdExctnDt", "PoolgAdjstmntDt", "Dbtr", "DbtrAcct", "DbtrAgt", "DbtrAgtAcct", "UltmtDbtr", "ChrgBr", "CdtTrfTxInf",
+	})
+	checkOrder(t, children[root+"/PmtInf/CdtTrfTxInf"], []string{"PmtId", "PmtTpInf", "Amt", "XchgRateInf", "ChrgBr",
+		"ChqInstr", "UltmtDbtr", "IntrmyAgt1", "CdtrAgt", "CdtrAgtAcct", "Cdtr", "CdtrAcct", "UltmtCdtr", "InstrForCdtrAgt",
+		"Purp", "RgltryRptg", "Tax", "RltdRmtInf", "RmtInf",
+	})
+
+	expected := map[string]string{
+		root + "/GrpHdr/NbOfTxs":                                                "2",
+		root + "/GrpHdr/CtrlSum":                                                "176000.50",
+		root + "/PmtInf/ReqdExctnDt":                                            "2024-06-10",
+		root + "/PmtInf/DbtrAcct/Id/Othr/Id":                                    payer.Account,
+		root + "/PmtInf/DbtrAgt/FinInstnId/ClrSysMmbId/MmbId":                   payer.Bank.Bik,
+		root + "/PmtInf/DbtrAgtAcct/Id/Othr/Id":                                 payer.Bank.Account,
+		root + "/PmtInf/Dbtr/Id/OrgId/Othr/Id":                                  payer.Inn,
+		root + "/PmtInf/CdtTrfTxInf/Tax/Rcrd/Tp":                                "18210101011011000110",
+		root + "/PmtInf/CdtTrfTxInf/Tax/AdmstnZn":                               "45382000",
+		root + "/PmtInf/CdtTrfTxInf/Tax/Dbtr/TaxTp":                             "01",
+		root + "/PmtInf/CdtTrfTxInf/CdtrAgt/FinInstnId/ClrSysMmbId/ClrSysId/Cd": PAIN_CLR_SYS_RU,
+	}
+	for p, v := range expected {
+		if len(values[p]) == 0 || values[p][0] != v {
+			t.Fatalf("%s, expected %s, got %v", p, v, values[p])
+		}
+	}
+	if amts := values[root+"/PmtInf/CdtTrfTxInf/Amt/InstdAmt"]; len(amts) != 2 || amts[1] != "1000.50" {
+		t.Fatalf("instructed amounts, got %v", amts)
+	}
+	if n := len(values[root+"/PmtInf/PmtInfId"]); n != 1 {
+		t.Fatalf("PmtInf count, expected 1, got %d", n)
+	}
+
+	//message id is unique for every export unless given
+	b, err = exp.MarshalPain001()
+	if err != nil {
+		t.Fatalf("MarshalPain001() failed: %v", err)
+	}
+	values2, _ := xmlPaths(t, b)
+	if id := values[root+"/GrpHdr/MsgId"][0]; id == values2[root+"/GrpHdr/MsgId"][0] || len(id) > 35 {
+		t.Fatalf("generated message id %s must be unique and at most 35 characters", id)
+	}
+	exp.MsgId = "MSG-1"
+	if b, err = exp.MarshalPain001(); err != nil {
+		t.Fatalf("MarshalPain001() failed: %v", err)
+	}
+	if values2, _ = xmlPaths(t, b); values2[root+"/GrpHdr/MsgId"][0] != "MSG-1" {
+		t.Fatalf("message id, expected MSG-1, got %v", values2[root+"/GrpHdr/MsgId"])
+	}
+
+	//payment information id fits 35 characters
+	exp.MsgId = strings.Repeat("М", 35)
+	if b, err = exp.MarshalPain001(); err != nil {
+		t.Fatalf("MarshalPain001() with 35 character message id failed: %v", err)
+	}
+	values2, _ = xmlPaths(t, b)
+	if id := values2[root+"/PmtInf/PmtInfId"][0]; id != strings.Repeat("М", 33)+"-1" {
+		t.Fatalf("payment information id, got %s", id)
+	}
+	exp.MsgId += "1"
+	if _, err = exp.MarshalPain001(); err == nil {
+		t.Fatal("MarshalPain001() with message id longer than 35 characters must fail")
+	}
+}
@@ -0,0 +1,66 @@
+package clbnk
+
+// Party field prefixes.
+const (
+	PAYER_PREFIX    = "Плательщик"
+	RECEIVER_PREFIX = "Получатель"
+)
+
+// BankInfo is a bank block of a payer or a receiver.
+type BankInfo struct {
+	Name    string `bank:"Банк1" json:"name"`
+	Place   string `bank:"Банк2" json:"place"`
+	Bik     string `bank:"БИК" json:"bik"`
+	Account string `bank:"Корсчет" json:"account"` // correspondent account
+}
+
+// Party is a payer or a receiver block of a document.
+// It is embedded into documents with bankPrefix tag,
+// all field names are prefixed with the tag value.
+type Party struct {
+	Firm              string   `bankFirmName:"1" json:"firm"` // composite value of Плательщик/Получатель
+	Inn               string   `bank:"ИНН" json:"inn"`
+	Name              string   `bank:"1" json:"name"`
+	Name2             string   `bank:"2" json:"name2"`
+	Name3             string   `bank:"3" json:"name3"`
+	Name4             string   `bank:"4" json:"name4"`
+	Account           string   `bank:"Счет" json:"account"`
+	SettlementAccount string   `bank:"РасчСчет" bankOmitEmpty:"1" json:"settlementAccount"` // account for indirect settlements
+	Bank              BankInfo `bankPrefix:"" json:"bank"`
+	Kpp               string   `bank:"КПП" bankOmitEmpty:"1" json:"kpp"`
+}
+
+// FirmName returns composite firm name for Плательщик/Получатель field.
+// If Firm value is set it is returned as is. Otherwise the value is
+// ИНН + inn + name for ordinary payments and the name without INN
+// for budget payments, as INN is a separate field there.
+// For budget payments and for accounts at another bank (indirect settlements)
+// the account, bank name and bank place given in Name2..Name4 are added:
+// name р/с account в bank place.
+func (p *Party) FirmName(budget bool) string {
+	if p.Firm != "" {
+		return p.Firm
+	}
+	var name string
+	if budget {
+		name = p.Name
+	} else {
+		name = string(marshalFirmName(p.Inn, p.Name))
+	}
+	if p.Name2 != "" && (budget || p.anotherBank()) {
+		name += " р/с " + p.Name2
+		if p.Name3 != "" {
+			name += " в " + p.Name3
+		}
+		if p.Name4 != "" {
+			name += " " + p.Name4
+		}
+	}
+	return name
+}
+
+// anotherBank returns true if the bank given in Name3 differs
+// from the bank of the document.
+func (p *Party) anotherBank() bool {
+	return p.Name3 != "" && p.Name3 != p.Bank.Name
+}
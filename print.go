@@ -0,0 +1,214 @@
+package clbnk
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+)
+
+const FORM_0401060 = "0401060"
+
+// formSum formats the sum for printed forms: rubles-kopecks,
+// 175000= for sums without kopecks.
+func formSum(sum float64) string {
+	kop_total := int64(math.Round(sum * 100))
+	if kop_total%100 == 0 {
+		return fmt.Sprintf("%d=", kop_total/100)
+	}
+	return fmt.Sprintf("%d-%02d", kop_total/100, kop_total%100)
+}
+
+func formDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02.01.2006")
+}
+
+var paymentOrderTemplate = template.Must(template.New(FORM_0401060).Funcs(template.FuncMap{
+	"sum":  formSum,
+	"date": formDate,
+}).Parse(paymentOrderHTML))
+
+// RenderPaymentOrdersHTML renders printable payment orders (form 0401060)
+// as an HTML document with print styles, one order per page.
+func RenderPaymentOrdersHTML(w io.Writer, docs []*PPDocument) error {
+	return paymentOrderTemplate.Execute(w, docs)
+}
+
+// RenderHTML renders the printable payment order (form 0401060) as an HTML document.
+func (d *PPDocument) RenderHTML(w io.Writer) error {
+	return RenderPaymentOrdersHTML(w, []*PPDocument{d})
+}
+
+const paymentOrderHTML = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Платежное поручение</title>
+<style>
+@page { size: A4 portrait; margin: 10mm 10mm 10mm 20mm; }
+body { font-family: "Times New Roman", Times, serif; font-size: 10pt; margin: 0; }
+.page { width: 180mm; page-break-after: always; }
+.page:last-child { page-break-after: auto; }
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+td { vertical-align: top; padding: 1mm; overflow: hidden; }
+.b { border: 1px solid #000; }
+.bb { border-bottom: 1px solid #000; }
+.bt { border-top: 1px solid #000; }
+.bl { border-left: 1px solid #000; }
+.br { border-right: 1px solid #000; }
+.c { text-align: center; }
+.r { text-align: right; }
+.small { font-size: 7pt; }
+.title { font-size: 12pt; font-weight: bold; }
+.h10 { height: 10mm; }
+.h15 { height: 15mm; }
+.sign { height: 15mm; }
+@media screen { .page { margin: 10mm auto; border: 1px dashed #999; padding: 10mm; } }
+</style>
+</head>
+<body>
+{{range .}}<div class="page">
+<table>
+<tr>
+<td class="bb" style="width:35mm">{{date .DebetDate}}</td>
+<td style="width:10mm"></td>
+<td class="bb" style="width:35mm">{{date .KreditDate}}</td>
+<td></td>
+<td class="b c" style="width:20mm">{{.CompilerStatus}}</td>
+<td class="b c" style="width:20mm">` + FORM_0401060 + `</td>
+</tr>
+<tr class="small">
+<td>Поступ. в банк плат.</td><td></td><td>Списано со сч. плат.</td><td></td><td></td><td></td>
+</tr>
+</table>
+<table>
+<tr>
+<td class="title" style="width:70mm">ПЛАТЕЖНОЕ ПОРУЧЕНИЕ № {{.Num}}</td>
+<td class="bb c" style="width:35mm">{{date .Date}}</td>
+<td style="width:10mm"></td>
+<td class="bb c" style="width:35mm">{{.PayType}}</td>
+<td></td>
+</tr>
+<tr class="small">
+<td></td><td class="c">Дата</td><td></td><td class="c">Вид платежа</td><td></td>
+</tr>
+</table>
+<table>
+<tr>
+<td class="br" style="width:20mm">Сумма прописью</td>
+<td>{{.SumInWords}}</td>
+</tr>
+</table>
+<table>
+<tr>
+<td class="b" style="width:45mm">ИНН {{.Payer.Inn}}</td>
+<td class="b" style="width:45mm">КПП {{.Payer.Kpp}}</td>
+<td class="b" style="width:20mm">Сумма</td>
+<td class="b">{{sum .Sum}}</td>
+</tr>
+<tr>
+<td class="bl br h10" colspan="2">{{.Payer.Name}}</td>
+<td class="b" rowspan="2">Сч. №</td>
+<td class="b" rowspan="2">{{.Payer.Account}}</td>
+</tr>
+<tr>
+<td class="bl br bb small" colspan="2">Плательщик</td>
+</tr>
+<tr>
+<td class="bl br" colspan="2">{{.Payer.Bank.Name}} {{.Payer.Bank.Place}}</td>
+<td class="b">БИК</td>
+<td class="bl br">{{.Payer.Bank.Bik}}</td>
+</tr>
+<tr>
+<td class="bl br bb small" colspan="2">Банк плательщика</td>
+<td class="b">Сч. №</td>
+<td class="bl br bb">{{.Payer.Bank.Account}}</td>
+</tr>
+<tr>
+<td class="bl br" colspan="2">{{.Receiver.Bank.Name}} {{.Receiver.Bank.Place}}</td>
+<td class="b">БИК</td>
+<td class="bl br">{{.Receiver.Bank.Bik}}</td>
+</tr>
+<tr>
+<td class="bl br bb small" colspan="2">Банк получателя</td>
+<td class="b">Сч. №</td>
+<td class="bl br bb">{{.Receiver.Bank.Account}}</td>
+</tr>
+<tr>
+<td class="b">ИНН {{.Receiver.Inn}}</td>
+<td class="b">КПП {{.Receiver.Kpp}}</td>
+<td class="b" rowspan="2">Сч. №</td>
+<td class="b" rowspan="2">{{.Receiver.Account}}</td>
+</tr>
+<tr>
+<td class="bl br h10" colspan="2">{{.Receiver.Name}}</td>
+</tr>
+</table>
+<table>
+<tr>
+<td class="bl br" rowspan="3" style="width:90mm"></td>
+<td class="b" style="width:20mm">Вид оп.</td>
+<td class="b" style="width:20mm">{{.OplType}}</td>
+<td class="b" style="width:20mm">Срок плат.</td>
+<td class="b"></td>
+</tr>
+<tr>
+<td class="b">Наз. пл.</td>
+<td class="b"></td>
+<td class="b">Очер. плат.</td>
+<td class="b">{{.Order}}</td>
+</tr>
+<tr>
+<td class="b">Код</td>
+<td class="b">{{.Code}}</td>
+<td class="b">Рез. поле</td>
+<td class="b"></td>
+</tr>
+<tr>
+<td class="bl br bb small">Получатель</td>
+<td colspan="4"></td>
+</tr>
+</table>
+<table>
+<tr class="c">
+<td class="b" style="width:45mm">{{.KBKValue}}</td>
+<td class="b" style="width:25mm">{{.OKATOValue}}</td>
+<td class="b" style="width:10mm">{{.OsnovanieValue}}</td>
+<td class="b" style="width:25mm">{{.PeriodValue}}</td>
+<td class="b" style="width:30mm">{{.NomerValue}}</td>
+<td class="b" style="width:25mm">{{.DateValue}}</td>
+<td class="b">{{.TipValue}}</td>
+</tr>
+</table>
+<table>
+<tr>
+<td class="h15">{{.PayComment}}</td>
+</tr>
+<tr>
+<td class="bb small">Назначение платежа</td>
+</tr>
+</table>
+<table>
+<tr>
+<td style="width:60mm"></td>
+<td class="c" style="width:60mm">Подписи</td>
+<td class="c">Отметки банка</td>
+</tr>
+<tr>
+<td class="c sign">М.П.</td>
+<td class="bb sign"></td>
+<td rowspan="2"></td>
+</tr>
+<tr>
+<td></td>
+<td class="bb sign"></td>
+</tr>
+</table>
+</div>
+{{end}}</body>
+</html>
+`
@@ -0,0 +1,75 @@
+package clbnk
+
+// PayerProfile is a company account used as the payer of exported documents.
+// The profile is validated once on creation and can not be changed.
+type PayerProfile struct {
+	party Party
+}
+
+// NewPayerProfile validates the party and creates a profile.
+func NewPayerProfile(party Party) (*PayerProfile, error) {
+	if err := party.Validate(PAYER_PREFIX); err != nil {
+		return nil, err
+	}
+	return &PayerProfile{party: party}, nil
+}
+
+// Party returns the payer party of the profile.
+func (p *PayerProfile) Party() Party {
+	return p.party
+}
+
+// NewPPDocument returns a new payment order with the profile payer.
+func (p *PayerProfile) NewPPDocument() *PPDocument {
+	return &PPDocument{Payer: p.party}
+}
+
+// Apply fills empty fields of the payer party with profile values.
+// If the party has another account, only company fields (name, INN, KPP)
+// are filled, as the profile bank does not serve that account.
+func (p *PayerProfile) Apply(payer *Party) {
+	setEmpty := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	setEmpty(&payer.Name, p.party.Name)
+	setEmpty(&payer.Inn, p.party.Inn)
+	setEmpty(&payer.Kpp, p.party.Kpp)
+	if payer.Account != "" && payer.Account != p.party.Account {
+		return
+	}
+	setEmpty(&payer.Account, p.party.Account)
+	setEmpty(&payer.SettlementAccount, p.party.SettlementAccount)
+	setEmpty(&payer.Name2, p.party.Name2)
+	setEmpty(&payer.Name3, p.party.Name3)
+	setEmpty(&payer.Name4, p.party.Name4)
+	setEmpty(&payer.Bank.Name, p.party.Bank.Name)
+	setEmpty(&payer.Bank.Place, p.party.Bank.Place)
+	setEmpty(&payer.Bank.Bik, p.party.Bank.Bik)
+	setEmpty(&payer.Bank.Account, p.party.Bank.Account)
+}
+
+// ProfileApplier is implemented by documents inheriting
+// empty payer fields from a payer profile.
+type ProfileApplier interface {
+	ApplyProfile(p *PayerProfile)
+}
+
+// ApplyProfile fills empty payer fields with profile values.
+func (d *PPDocument) ApplyProfile(p *PayerProfile) {
+	p.Apply(&d.Payer)
+}
+
+// applyProfile fills empty payer fields of all documents
+// with values of the export payer profile.
+func (e *BankExport) applyProfile() {
+	if e.Payer == nil {
+		return
+	}
+	for _, doc := range e.Documents {
+		if d, ok := doc.(ProfileApplier); ok {
+			d.ApplyProfile(e.Payer)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package clbnk
+
+import (
+	"regexp"
+	"time"
+)
+
+// DocumentView is a read-only view of an import document
+// with the most commonly used values.
+type DocumentView struct {
+	Type      DocumentType
+	Num       int
+	Date      time.Time
+	Sum       float64
+	Currency  string
+	Payer     Party
+	Receiver  Party
+	Purpose   string
+	Direction Direction
+	Document  BankImportDocument // source document
+}
+
+// NewDocumentView creates a view of the document.
+// Direction is determined relative to the account.
+func NewDocumentView(doc BankImportDocument, account string) DocumentView {
+	return DocumentView{Type: doc.GetType(),
+		Num:       doc.GetNum(),
+		Date:      doc.GetDate(),
+		Sum:       doc.GetSum(),
+		Currency:  doc.GetCurrency(),
+		Payer:     doc.GetPayer(),
+		Receiver:  doc.GetReceiver(),
+		Purpose:   doc.GetPurpose(),
+		Direction: doc.Direction(account),
+		Document:  doc,
+	}
+}
+
+// Counterparty returns payer for incoming documents,
+// receiver for outgoing documents.
+func (v *DocumentView) Counterparty() Party {
+	if v.Direction == DIRECTION_INCOMING {
+		return v.Payer
+	}
+	return v.Receiver
+}
+
+// CounterpartyInn returns payer INN for incoming documents,
+// receiver INN for outgoing documents.
+func (v *DocumentView) CounterpartyInn() string {
+	return v.Counterparty().Inn
+}
+
+// CounterpartyName returns payer name for incoming documents,
+// receiver name for outgoing documents.
+func (v *DocumentView) CounterpartyName() string {
+	return v.Counterparty().Name
+}
+
+// DocumentFilter returns true if the document must be included.
+type DocumentFilter func(v *DocumentView) bool
+
+// FilterDateRange selects documents with dates between from and to inclusive.
+// Zero values mean no bound.
+func FilterDateRange(from, to time.Time) DocumentFilter {
+	return func(v *DocumentView) bool {
+		if !from.IsZero() && v.Date.Before(from) {
+			return false
+		}
+		if !to.IsZero() && v.Date.After(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// FilterInn selects documents with the counterparty INN.
+// If direction is not defined both payer and receiver are checked.
+func FilterInn(inn string) DocumentFilter {
+	return func(v *DocumentView) bool {
+		if v.Direction == DIRECTION_NOT_DEFINED {
+			return v.Payer.Inn == inn || v.Receiver.Inn == inn
+		}
+		return v.CounterpartyInn() == inn
+	}
+}
+
+// FilterAccount selects documents with the account either as payer or as receiver.
+func FilterAccount(account string) DocumentFilter {
+	return func(v *DocumentView) bool {
+		return v.Payer.Account == account || v.Receiver.Account == account
+	}
+}
+
+// FilterSumRange selects documents with sums between min and max inclusive.
+// Zero values mean no bound.
+func FilterSumRange(min, max float64) DocumentFilter {
+	return func(v *DocumentView) bool {
+		if min != 0 && v.Sum < min {
+			return false
+		}
+		if max != 0 && v.Sum > max {
+			return false
+		}
+		return true
+	}
+}
+
+// FilterCurrency selects documents in the currency.
+func FilterCurrency(currency string) DocumentFilter {
+	return func(v *DocumentView) bool {
+		return v.Currency == currency
+	}
+}
+
+// FilterDirection selects documents with the direction.
+func FilterDirection(direction Direction) DocumentFilter {
+	return func(v *DocumentView) bool {
+		return v.Direction == direction
+	}
+}
+
+// FilterPurpose selects documents with payment purpose matching the expression.
+func FilterPurpose(re *regexp.Regexp) DocumentFilter {
+	return func(v *DocumentView) bool {
+		return re.MatchString(v.Purpose)
+	}
+}
+
+// Views returns views of all documents. Direction is determined
+// relative to the account, empty account means statement account.
+func (e *BankImport) Views(account string) []DocumentView {
+	if account == "" {
+		account = e.Account
+	}
+	views := make([]DocumentView, 0, len(e.Documents))
+	for _, doc := range e.Documents {
+		views = append(views, NewDocumentView(doc, account))
+	}
+	return views
+}
+
+// Filter returns views of documents matching all filters.
+func (e *BankImport) Filter(account string, filters ...DocumentFilter) []DocumentView {
+	views := make([]DocumentView, 0)
+	for _, v := range e.Views(account) {
+		ok := true
+		for _, f := range filters {
+			if !f(&v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			views = append(views, v)
+		}
+	}
+	return views
+}
@@ -0,0 +1,271 @@
+package clbnk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Payment register import.
+// A register is a list of payments prepared in a spreadsheet (CSV)
+// or as a JSON array of objects. Mapping gives a document field for every
+// register column as a path of JSON field names of PPDocument,
+// for example "receiver.inn" or "receiver.bank.bik".
+
+// RowError is an error of a register row.
+type RowError struct {
+	Row int // CSV line number including header, JSON array element number, starting with 1
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterError contains errors of all invalid rows.
+type RegisterError []*RowError
+
+func (e RegisterError) Error() string {
+	s := make([]string, 0, len(e))
+	for _, r := range e {
+		s = append(s, r.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+// PaymentRegister builds payment orders from register rows.
+type PaymentRegister struct {
+	// Mapping maps column names to document field paths.
+	// If it is empty, column names are taken as field paths.
+	// Columns without mapping are skipped.
+	Mapping map[string]string
+	// Template contains values of every document before row values are set,
+	// for example payer, Order or PayType.
+	Template *PPDocument
+	// Payer fills empty payer fields of every document.
+	Payer *PayerProfile
+	Comma rune // CSV field delimiter
+	// Rows are register row numbers of documents read by the last ReadCSV or ReadJSON call,
+	// in the RowError.Row numbering.
+	Rows []int
+}
+
+// NewPaymentRegister creates a register with the mapping and ; as CSV delimiter.
+func NewPaymentRegister(mapping map[string]string) *PaymentRegister {
+	return &PaymentRegister{Mapping: mapping, Comma: ';'}
+}
+
+// fieldPath returns the document field path of the column.
+func (r *PaymentRegister) fieldPath(column string) (string, bool) {
+	if len(r.Mapping) == 0 {
+		return column, true
+	}
+	path, ok := r.Mapping[column]
+	return path, ok
+}
+
+// checkColumns returns an error if mapped columns are absent.
+func (r *PaymentRegister) checkColumns(columns map[string]bool) error {
+	for col := range r.Mapping {
+		if !columns[col] {
+			return fmt.Errorf("column %s not found", col)
+		}
+	}
+	return nil
+}
+
+// newDocument creates a document from column values and validates it.
+func (r *PaymentRegister) newDocument(values map[string]string) (*PPDocument, error) {
+	doc := &PPDocument{}
+	if r.Template != nil {
+		*doc = *r.Template
+	}
+	v := reflect.ValueOf(doc).Elem()
+	errs := make([]error, 0)
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	for _, col := range columns {
+		val := values[col]
+		path, ok := r.fieldPath(col)
+		if !ok {
+			continue
+		}
+		field, err := fieldByJSONPath(v, path)
+		if err == nil {
+			err = setRegisterValue(field, strings.TrimSpace(val))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", col, err))
+		}
+	}
+	if r.Payer != nil {
+		doc.ApplyProfile(r.Payer)
+	}
+	if len(errs) == 0 {
+		if err := doc.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ReadCSV reads documents from CSV data with column names in the first line.
+// All documents are validated, RegisterError is returned if any row is invalid.
+func (r *PaymentRegister) ReadCSV(rd io.Reader) ([]*PPDocument, error) {
+	csv_r := csv.NewReader(rd)
+	if r.Comma != 0 {
+		csv_r.Comma = r.Comma
+	}
+	csv_r.TrimLeadingSpace = true
+	header, err := csv_r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("register header: %v", err)
+	}
+	columns := make(map[string]bool)
+	for i, col := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))
+		columns[header[i]] = true
+	}
+	if err := r.checkColumns(columns); err != nil {
+		return nil, err
+	}
+
+	docs := make([]*PPDocument, 0)
+	r.Rows = make([]int, 0)
+	var reg_err RegisterError
+	for row := 2; ; row++ {
+		rec, err := csv_r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			reg_err = append(reg_err, &RowError{Row: row, Err: err})
+			continue
+		}
+		values := make(map[string]string)
+		empty := true
+		for i, val := range rec {
+			if i < len(header) {
+				values[header[i]] = val
+			}
+			empty = empty && strings.TrimSpace(val) == ""
+		}
+		if empty {
+			continue
+		}
+		doc, err := r.newDocument(values)
+		if err != nil {
+			reg_err = append(reg_err, &RowError{Row: row, Err: err})
+			continue
+		}
+		docs = append(docs, doc)
+		r.Rows = append(r.Rows, row)
+	}
+	if len(reg_err) > 0 {
+		return nil, reg_err
+	}
+	return docs, nil
+}
+
+// ReadJSON reads documents from JSON array of objects with column names as keys.
+// All documents are validated, RegisterError is returned if any row is invalid.
+func (r *PaymentRegister) ReadJSON(rd io.Reader) ([]*PPDocument, error) {
+	dec := json.NewDecoder(rd)
+	dec.UseNumber()
+	rows := make([]map[string]interface{}, 0)
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("register: %v", err)
+	}
+	docs := make([]*PPDocument, 0, len(rows))
+	r.Rows = make([]int, 0, len(rows))
+	var reg_err RegisterError
+	for i, row := range rows {
+		values := make(map[string]string)
+		columns := make(map[string]bool)
+		for col, val := range row {
+			columns[col] = true
+			switch v := val.(type) {
+			case nil:
+				values[col] = ""
+			case string:
+				values[col] = v
+			case json.Number:
+				values[col] = v.String()
+			default:
+				values[col] = fmt.Sprint(v)
+			}
+		}
+		err := r.checkColumns(columns)
+		var doc *PPDocument
+		if err == nil {
+			doc, err = r.newDocument(values)
+		}
+		if err != nil {
+			reg_err = append(reg_err, &RowError{Row: i + 1, Err: err})
+			continue
+		}
+		docs = append(docs, doc)
+		r.Rows = append(r.Rows, i+1)
+	}
+	if len(reg_err) > 0 {
+		return nil, reg_err
+	}
+	return docs, nil
+}
+
+// fieldByJSONPath returns the struct field by the path of JSON field names.
+func fieldByJSONPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("field %s not found", path)
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if tag == name {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("field %s not found", path)
+		}
+	}
+	return v, nil
+}
+
+// setRegisterValue sets the field value, sums may have spaces
+// and a decimal comma, dates may also be in 2006-01-02 format.
+// Empty values keep template values.
+func setRegisterValue(field reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch {
+	case field.Type() == reflect.TypeOf(time.Time{}):
+		if t, err := time.Parse("2006-01-02", value); err == nil {
+			field.Set(reflect.ValueOf(t))
+			return nil
+		}
+	case field.Kind() == reflect.Float64 || field.Kind() == reflect.Float32:
+		value = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(value)
+	}
+	return setFieldValue(field, value, false, "", nil, nil)
+}
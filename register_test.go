@@ -0,0 +1,111 @@
+package clbnk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRegisterMapping = map[string]string{"Номер": "num",
+	"Дата":            "date",
+	"Сумма":           "sum",
+	"Получатель":      "receiver.name",
+	"ИНН получателя":  "receiver.inn",
+	"Счет получателя": "receiver.account",
+	"БИК банка":       "receiver.bank.bik",
+	"Банк получателя": "receiver.bank.name",
+	"Корсчет банка":   "receiver.bank.account",
+	"Назначение":      "payComment",
+	"Вид платежа":     "payType",
+}
+
+func testRegisterTemplate() *PPDocument {
+	return &PPDocument{Payer: Party{Name: `ООО "Рога и Копыта"`,
+		Inn:     "1234567891",
+		Account: "40702810000000000001",
+		Bank:    BankInfo{Name: "Банк", Bik: "044525225", Account: "30101810400000000225"},
+	},
+		PayType: PAY_TYPE_DIG,
+		Order:   5,
+	}
+}
+
+const testRegisterCSV = `Номер;Дата;Сумма;Получатель;ИНН получателя;Счет получателя;БИК банка;Банк получателя;Корсчет банка;Назначение;Вид платежа;Комментарий
+1;09.01.2024;1 500,50;ИП Иванов А.А.;111122223344;40802810000000000002;044525225;Банк;30101810400000000225;За товары по счету №1;;не выгружается
+2;2024-01-10;300;"ООО ""Поставщик""";7712345678;40702810000000000003;044525225;Банк;30101810400000000225;За услуги;Срочно;
+`
+
+func TestRegisterCSV(t *testing.T) {
+	reg := NewPaymentRegister(testRegisterMapping)
+	reg.Template = testRegisterTemplate()
+	docs, err := reg.ReadCSV(strings.NewReader(testRegisterCSV))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("document count, expected 2, got %d", len(docs))
+	}
+	if len(reg.Rows) != 2 || reg.Rows[0] != 2 || reg.Rows[1] != 3 {
+		t.Fatalf("document rows, expected [2 3], got %v", reg.Rows)
+	}
+	d := docs[0]
+	if d.Num != 1 || d.Sum != 1500.5 || !d.Date.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("document values, got %+v", d)
+	}
+	if d.Payer.Inn != "1234567891" || d.Receiver.Bank.Bik != "044525225" || d.PayType != PAY_TYPE_DIG {
+		t.Fatalf("document template values, got %+v", d)
+	}
+	if docs[1].Receiver.Name != `ООО "Поставщик"` || docs[1].PayType != PAY_TYPE_URGENT || docs[1].Date.Day() != 10 {
+		t.Fatalf("second document values, got %+v", docs[1])
+	}
+
+	if _, err := NewBankExport([]BankExportDocument{docs[0], docs[1]}).Marshal(); err != nil {
+		t.Fatalf("Marshal of register documents failed: %v", err)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	reg := NewPaymentRegister(testRegisterMapping)
+	reg.Template = testRegisterTemplate()
+	bad := strings.Replace(testRegisterCSV, "1 500,50", "много", 1)
+	bad = strings.Replace(bad, "7712345678", "77", 1)
+	docs, err := reg.ReadCSV(strings.NewReader(bad))
+	if docs != nil || err == nil {
+		t.Fatal("ReadCSV of invalid register must fail")
+	}
+	var reg_err RegisterError
+	if !errors.As(err, &reg_err) || len(reg_err) != 2 {
+		t.Fatalf("expected 2 row errors, got %v", err)
+	}
+	if reg_err[0].Row != 2 || !strings.Contains(reg_err[0].Error(), "Сумма") {
+		t.Fatalf("first row error, got %v", reg_err[0])
+	}
+	if reg_err[1].Row != 3 || !strings.Contains(reg_err[1].Error(), "ПолучательИНН") {
+		t.Fatalf("second row error, got %v", reg_err[1])
+	}
+
+	if _, err := reg.ReadCSV(strings.NewReader("Номер;Сумма\n1;100\n")); err == nil {
+		t.Fatal("ReadCSV without mapped columns must fail")
+	}
+}
+
+func TestRegisterJSON(t *testing.T) {
+	reg := NewPaymentRegister(nil)
+	reg.Template = testRegisterTemplate()
+	docs, err := reg.ReadJSON(strings.NewReader(`[{"num": 7, "date": "2024-01-09", "sum": 1000.25,
+		"receiver.name": "ИП Иванов А.А.", "receiver.inn": "111122223344",
+		"receiver.account": "40802810000000000002", "receiver.bank.bik": "044525225",
+		"payComment": "За товары"}]`))
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Num != 7 || docs[0].Sum != 1000.25 || docs[0].Receiver.Account != "40802810000000000002" {
+		t.Fatalf("document values, got %+v", docs)
+	}
+	_, err = reg.ReadJSON(strings.NewReader(`[{"num": 1}, {"num": 2, "unknown": 1}]`))
+	var reg_err RegisterError
+	if !errors.As(err, &reg_err) || len(reg_err) != 2 || reg_err[1].Row != 2 || !strings.Contains(reg_err[1].Error(), "unknown") {
+		t.Fatalf("expected 2 row errors, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package clbnk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	wordsUnitsMale   = []string{"", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	wordsUnitsFemale = []string{"", "одна", "две", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	wordsTeens       = []string{"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать",
+		"пятнадцать", "шестнадцать", "семнадцать", "восемнадцать", "девятнадцать",
+	}
+	wordsTens = []string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят",
+		"шестьдесят", "семьдесят", "восемьдесят", "девяносто",
+	}
+	wordsHundreds = []string{"", "сто", "двести", "триста", "четыреста", "пятьсот",
+		"шестьсот", "семьсот", "восемьсот", "девятьсот",
+	}
+)
+
+// wordsGroup is a thousand group with its plural forms: one, few, many.
+type wordsGroup struct {
+	forms  [3]string
+	female bool
+}
+
+// wordsGroups from the lowest: thousands, millions, billions, trillions,
+// quadrillions, quintillions. They cover the int64 range.
+var wordsGroups = []wordsGroup{
+	{forms: [3]string{"тысяча", "тысячи", "тысяч"}, female: true},
+	{forms: [3]string{"миллион", "миллиона", "миллионов"}},
+	{forms: [3]string{"миллиард", "миллиарда", "миллиардов"}},
+	{forms: [3]string{"триллион", "триллиона", "триллионов"}},
+	{forms: [3]string{"квадриллион", "квадриллиона", "квадриллионов"}},
+	{forms: [3]string{"квинтиллион", "квинтиллиона", "квинтиллионов"}},
+}
+
+// currencyWords are plural forms of currency units and their fractions.
+type currencyWords struct {
+	units     [3]string
+	female    bool // units are female (одна лира)
+	fractions [3]string
+}
+
+var (
+	wordsKopecks = [3]string{"копейка", "копейки", "копеек"}
+	wordsCents   = [3]string{"цент", "цента", "центов"}
+)
+
+// currenciesWords are unit words of currencies supported by CurrencySumInWords.
+var currenciesWords = map[string]currencyWords{
+	CURRENCY_RUB: {units: [3]string{"рубль", "рубля", "рублей"}, fractions: wordsKopecks},
+	CURRENCY_USD: {units: [3]string{"доллар США", "доллара США", "долларов США"}, fractions: wordsCents},
+	CURRENCY_EUR: {units: [3]string{"евро", "евро", "евро"}, fractions: wordsCents},
+	CURRENCY_CNY: {units: [3]string{"юань", "юаня", "юаней"}, fractions: [3]string{"фэнь", "фэня", "фэней"}},
+	CURRENCY_GBP: {units: [3]string{"фунт стерлингов", "фунта стерлингов", "фунтов стерлингов"}, fractions: [3]string{"пенс", "пенса", "пенсов"}},
+	CURRENCY_CHF: {units: [3]string{"швейцарский франк", "швейцарских франка", "швейцарских франков"}, fractions: [3]string{"сантим", "сантима", "сантимов"}},
+	CURRENCY_JPY: {units: [3]string{"иена", "иены", "иен"}, female: true, fractions: [3]string{"сен", "сена", "сенов"}},
+	CURRENCY_KZT: {units: [3]string{"тенге", "тенге", "тенге"}, fractions: [3]string{"тиын", "тиына", "тиынов"}},
+	CURRENCY_BYN: {units: [3]string{"белорусский рубль", "белорусских рубля", "белорусских рублей"}, fractions: wordsKopecks},
+	CURRENCY_AED: {units: [3]string{"дирхам ОАЭ", "дирхама ОАЭ", "дирхамов ОАЭ"}, fractions: [3]string{"филс", "филса", "филсов"}},
+	CURRENCY_TRY: {units: [3]string{"турецкая лира", "турецкие лиры", "турецких лир"}, female: true, fractions: [3]string{"куруш", "куруша", "курушей"}},
+	CURRENCY_INR: {units: [3]string{"индийская рупия", "индийские рупии", "индийских рупий"}, female: true, fractions: [3]string{"пайса", "пайсы", "пайс"}},
+	CURRENCY_HKD: {units: [3]string{"гонконгский доллар", "гонконгских доллара", "гонконгских долларов"}, fractions: wordsCents},
+}
+
+// pluralForm returns the Russian plural form of the word for the number.
+func pluralForm(n int64, forms [3]string) string {
+	n = n % 100
+	if n >= 11 && n <= 14 {
+		return forms[2]
+	}
+	switch n % 10 {
+	case 1:
+		return forms[0]
+	case 2, 3, 4:
+		return forms[1]
+	}
+	return forms[2]
+}
+
+// tripletInWords returns words for the number from 0 to 999.
+func tripletInWords(n int64, female bool) []string {
+	words := make([]string, 0, 3)
+	if h := n / 100; h > 0 {
+		words = append(words, wordsHundreds[h])
+	}
+	n = n % 100
+	if n >= 10 && n < 20 {
+		return append(words, wordsTeens[n-10])
+	}
+	if t := n / 10; t > 0 {
+		words = append(words, wordsTens[t])
+	}
+	if u := n % 10; u > 0 {
+		if female {
+			words = append(words, wordsUnitsFemale[u])
+		} else {
+			words = append(words, wordsUnitsMale[u])
+		}
+	}
+	return words
+}
+
+// IntInWords returns the integer number in Russian words.
+// Female forms are used for units if female is true (одна, две).
+func IntInWords(n int64, female bool) string {
+	if n == 0 {
+		return "ноль"
+	}
+	words := make([]string, 0)
+	v := uint64(n)
+	if n < 0 {
+		words = append(words, "минус")
+		v = -v
+	}
+	triplets := make([]int64, 0)
+	for ; v > 0; v /= 1000 {
+		triplets = append(triplets, int64(v%1000))
+	}
+	for i := len(triplets) - 1; i >= 0; i-- {
+		t := triplets[i]
+		if t == 0 {
+			continue
+		}
+		if i == 0 {
+			words = append(words, tripletInWords(t, female)...)
+			continue
+		}
+		gr := wordsGroups[i-1]
+		words = append(words, tripletInWords(t, gr.female)...)
+		words = append(words, pluralForm(t, gr.forms))
+	}
+	return strings.Join(words, " ")
+}
+
+// SumInWords returns the amount in Russian words with rubles and kopecks,
+// for example "Сто семьдесят пять тысяч рублей 00 копеек".
+// An error is returned for negative, infinite, NaN amounts
+// and amounts out of int64 kopecks range.
+func SumInWords(sum float64) (string, error) {
+	return CurrencySumInWords(sum, CURRENCY_RUB)
+}
+
+// CurrencySumInWords returns the amount in Russian words with units of the currency,
+// for example "Двадцать один доллар США 50 центов". Empty currency means rubles,
+// an error is returned for currencies without unit words.
+func CurrencySumInWords(sum float64, currency string) (string, error) {
+	if currency == "" {
+		currency = CURRENCY_RUB
+	}
+	cur_words, ok := currenciesWords[currency]
+	if !ok {
+		return "", fmt.Errorf("currency %s: amount in words is not supported", currency)
+	}
+	if math.IsNaN(sum) || math.IsInf(sum, 0) || sum < 0 {
+		return "", fmt.Errorf("invalid amount: %v", sum)
+	}
+	kop_f := math.Round(sum * 100)
+	if kop_f >= math.MaxInt64 {
+		return "", fmt.Errorf("amount out of range: %v", sum)
+	}
+	kop_total := int64(kop_f)
+	rub := kop_total / 100
+	kop := kop_total % 100
+
+	s := fmt.Sprintf("%s %s %02d %s", IntInWords(rub, cur_words.female), pluralForm(rub, cur_words.units), kop, pluralForm(kop, cur_words.fractions))
+	return capitalize(s), nil
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,91 @@
+package clbnk
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSumInWords(t *testing.T) {
+	tests := []struct {
+		sum      float64
+		expected string
+	}{
+		{0, "Ноль рублей 00 копеек"},
+		{1, "Один рубль 00 копеек"},
+		{2.01, "Два рубля 01 копейка"},
+		{5.02, "Пять рублей 02 копейки"},
+		{11.11, "Одиннадцать рублей 11 копеек"},
+		{21.5, "Двадцать один рубль 50 копеек"},
+		{112, "Сто двенадцать рублей 00 копеек"},
+		{375.25, "Триста семьдесят пять рублей 25 копеек"},
+		{1000, "Одна тысяча рублей 00 копеек"},
+		{2002, "Две тысячи два рубля 00 копеек"},
+		{13056, "Тринадцать тысяч пятьдесят шесть рублей 00 копеек"},
+		{175000, "Сто семьдесят пять тысяч рублей 00 копеек"},
+		{1000000, "Один миллион рублей 00 копеек"},
+		{3214001.99, "Три миллиона двести четырнадцать тысяч один рубль 99 копеек"},
+		{2000000000, "Два миллиарда рублей 00 копеек"},
+		{5011000003.04, "Пять миллиардов одиннадцать миллионов три рубля 04 копейки"},
+		{1e15, "Один квадриллион рублей 00 копеек"},
+	}
+	for _, tt := range tests {
+		v, err := SumInWords(tt.sum)
+		if err != nil {
+			t.Fatalf("SumInWords(%.2f) failed: %v", tt.sum, err)
+		}
+		if v != tt.expected {
+			t.Fatalf("SumInWords(%.2f), expected %q, got %q", tt.sum, tt.expected, v)
+		}
+	}
+	for _, sum := range []float64{-10, math.NaN(), math.Inf(1), math.Inf(-1), 1e17} {
+		if _, err := SumInWords(sum); err == nil {
+			t.Fatalf("SumInWords(%v) must fail", sum)
+		}
+	}
+
+	doc := &PPDocument{Sum: 175000}
+	if v, err := doc.SumInWords(); err != nil || v != "Сто семьдесят пять тысяч рублей 00 копеек" {
+		t.Fatalf("PPDocument.SumInWords(), got %q, %v", v, err)
+	}
+}
+
+func TestCurrencySumInWords(t *testing.T) {
+	tests := []struct {
+		sum      float64
+		currency string
+		expected string
+	}{
+		{21.5, CURRENCY_USD, "Двадцать один доллар США 50 центов"},
+		{2, CURRENCY_EUR, "Два евро 00 центов"},
+		{1, CURRENCY_TRY, "Одна турецкая лира 00 курушей"},
+		{22.01, CURRENCY_CNY, "Двадцать два юаня 01 фэнь"},
+		{5, "", "Пять рублей 00 копеек"},
+	}
+	for _, tt := range tests {
+		if v, err := CurrencySumInWords(tt.sum, tt.currency); err != nil || v != tt.expected {
+			t.Fatalf("CurrencySumInWords(%.2f, %s), expected %q, got %q, %v", tt.sum, tt.currency, tt.expected, v, err)
+		}
+	}
+	if _, err := CurrencySumInWords(1, "XXX"); err == nil {
+		t.Fatal("CurrencySumInWords of unknown currency must fail")
+	}
+
+	//document currency from the payer account
+	doc := &PPDocument{Sum: 1000.5, Payer: Party{Account: "40702840000000001234"}}
+	if v, err := doc.SumInWords(); err != nil || v != "Одна тысяча долларов США 50 центов" {
+		t.Fatalf("PPDocument.SumInWords() in USD, got %q, %v", v, err)
+	}
+}
+
+func TestIntInWords(t *testing.T) {
+	if v := IntInWords(1e15, false); v != "один квадриллион" {
+		t.Fatalf("IntInWords(1e15), got %q", v)
+	}
+	if v := IntInWords(math.MaxInt64, false); !strings.HasPrefix(v, "девять квинтиллионов двести двадцать три квадриллиона") {
+		t.Fatalf("IntInWords(MaxInt64), got %q", v)
+	}
+	if v := IntInWords(math.MinInt64, false); !strings.HasPrefix(v, "минус девять квинтиллионов") || !strings.HasSuffix(v, "восемь") {
+		t.Fatalf("IntInWords(MinInt64), got %q", v)
+	}
+}
@@ -0,0 +1,265 @@
+package clbnk
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// StatementColumn is a column of statement table export.
+type StatementColumn int
+
+const (
+	COLUMN_DATE StatementColumn = iota
+	COLUMN_NUM
+	COLUMN_DIRECTION
+	COLUMN_COUNTERPARTY
+	COLUMN_INN
+	COLUMN_ACCOUNT
+	COLUMN_SUM
+	COLUMN_PURPOSE
+)
+
+// StatementColumnValues returns column titles.
+func StatementColumnValues() []string {
+	return []string{"Дата",
+		"Номер",
+		"Направление",
+		"Контрагент",
+		"ИНН",
+		"Счет",
+		"Сумма",
+		"Назначение платежа",
+	}
+}
+
+// String returns column title.
+func (c StatementColumn) String() string {
+	v := StatementColumnValues()
+	if c < 0 || int(c) >= len(v) {
+		return fmt.Sprintf("StatementColumn(%d)", int(c))
+	}
+	return v[int(c)]
+}
+
+// Balance row titles.
+const (
+	BALANCE_START_TITLE = "Начальный остаток"
+	BALANCE_END_TITLE   = "Конечный остаток"
+)
+
+// StatementTable exports imported statements as tables,
+// one row per document.
+type StatementTable struct {
+	Columns  []StatementColumn
+	Account  string // account for document direction, empty means statement account
+	Balances bool   // add start and end balance rows for every AccSection account
+	Comma    rune   // CSV field delimiter
+}
+
+// NewStatementTable creates a table with all columns and ; as CSV delimiter.
+func NewStatementTable() *StatementTable {
+	return &StatementTable{Columns: []StatementColumn{COLUMN_DATE,
+		COLUMN_NUM,
+		COLUMN_DIRECTION,
+		COLUMN_COUNTERPARTY,
+		COLUMN_INN,
+		COLUMN_ACCOUNT,
+		COLUMN_SUM,
+		COLUMN_PURPOSE,
+	},
+		Comma: ';',
+	}
+}
+
+// tableCell is a cell value, numeric cells are exported to XLSX as numbers.
+type tableCell struct {
+	value   string
+	numeric bool
+}
+
+func (t *StatementTable) header() []tableCell {
+	row := make([]tableCell, len(t.Columns))
+	for i, c := range t.Columns {
+		row[i] = tableCell{value: c.String()}
+	}
+	return row
+}
+
+func (t *StatementTable) documentRow(v *DocumentView) ([]tableCell, error) {
+	row := make([]tableCell, len(t.Columns))
+	counterparty := v.Counterparty()
+	for i, c := range t.Columns {
+		switch c {
+		case COLUMN_DATE:
+			row[i].value = formDate(v.Date)
+		case COLUMN_NUM:
+			row[i].value = strconv.Itoa(v.Num)
+		case COLUMN_DIRECTION:
+			row[i].value = v.Direction.String()
+		case COLUMN_COUNTERPARTY:
+			row[i].value = counterparty.Name
+		case COLUMN_INN:
+			row[i].value = counterparty.Inn
+		case COLUMN_ACCOUNT:
+			row[i].value = counterparty.Account
+		case COLUMN_SUM:
+			row[i] = tableCell{value: fmt.Sprintf("%.2f", v.Sum), numeric: true}
+		case COLUMN_PURPOSE:
+			row[i].value = v.Purpose
+		default:
+			return nil, fmt.Errorf("unknown column: %d", int(c))
+		}
+	}
+	return row, nil
+}
+
+// balanceRow returns a balance row: date, account, sum and title in the purpose column.
+func (t *StatementTable) balanceRow(acc *Account, start bool) []tableCell {
+	row := make([]tableCell, len(t.Columns))
+	for i, c := range t.Columns {
+		switch c {
+		case COLUMN_DATE:
+			if start {
+				row[i].value = formDate(acc.DateFrom)
+			} else {
+				row[i].value = formDate(acc.DateTo)
+			}
+		case COLUMN_ACCOUNT:
+			row[i].value = acc.Account
+		case COLUMN_SUM:
+			sum := acc.BalanceEnd
+			if start {
+				sum = acc.BalanceStart
+			}
+			row[i] = tableCell{value: fmt.Sprintf("%.2f", sum), numeric: true}
+		case COLUMN_PURPOSE:
+			if start {
+				row[i].value = BALANCE_START_TITLE
+			} else {
+				row[i].value = BALANCE_END_TITLE
+			}
+		}
+	}
+	return row
+}
+
+// rows returns all table rows with the header.
+func (t *StatementTable) rows(imp *BankImport) ([][]tableCell, error) {
+	rows := [][]tableCell{t.header()}
+	if t.Balances {
+		for i := range imp.AccSection {
+			rows = append(rows, t.balanceRow(&imp.AccSection[i], true))
+		}
+	}
+	for _, v := range imp.Views(t.Account) {
+		row, err := t.documentRow(&v)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if t.Balances {
+		for i := range imp.AccSection {
+			rows = append(rows, t.balanceRow(&imp.AccSection[i], false))
+		}
+	}
+	return rows, nil
+}
+
+// WriteCSV writes the statement as CSV with a header row.
+func (t *StatementTable) WriteCSV(w io.Writer, imp *BankImport) error {
+	rows, err := t.rows(imp)
+	if err != nil {
+		return err
+	}
+	wr := csv.NewWriter(w)
+	if t.Comma != 0 {
+		wr.Comma = t.Comma
+	}
+	for _, row := range rows {
+		rec := make([]string, len(row))
+		for i, c := range row {
+			rec[i] = c.value
+		}
+		if err := wr.Write(rec); err != nil {
+			return err
+		}
+	}
+	wr.Flush()
+	return wr.Error()
+}
+
+// WriteXLSX writes the statement as an XLSX workbook with one sheet.
+func (t *StatementTable) WriteXLSX(w io.Writer, imp *BankImport) error {
+	rows, err := t.rows(imp)
+	if err != nil {
+		return err
+	}
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			if cell.numeric {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, cell.value)
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>`, ref)
+			if err := xml.EscapeText(&sheet, []byte(cell.value)); err != nil {
+				return err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct {
+		name string
+		cont []byte
+	}{
+		{"[Content_Types].xml", []byte(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`)},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`)},
+		{"xl/workbook.xml", []byte(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Выписка" sheetId="1" r:id="rId1"/></sheets></workbook>`)},
+		{"xl/_rels/workbook.xml.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.cont); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xlsxColumn returns column letters by zero based index: A, B, ..., Z, AA...
+func xlsxColumn(i int) string {
+	col := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		col = string(rune('A'+(i-1)%26)) + col
+	}
+	return col
+}
@@ -47,24 +47,14 @@ func (e *BankImport) Unmarshal(data []byte) error {
 	if lines[0] != HEADER {
 		return fmt.Errorf("file header not found %v!=%v", []byte(lines[0]), []byte(HEADER))
 	}
+	//decode data from given encoding type or from the encoding of the file
+	var data_dec []byte
+	var err error
 	if e.EncodingType == ENCODING_TYPE_NOT_DEFINED {
-		enc := strings.Split(lines[2], "=")
-		if len(enc) < 2 {
-			return fmt.Errorf(ER_NO_ENC)
-		}
-		if enc[1] == ENCODING_WIN {
-			e.EncodingType = ENCODING_TYPE_WIN
-
-		} else if enc[1] == ENCODING_DOS {
-			e.EncodingType = ENCODING_TYPE_DOS
-
-		} else {
-			return fmt.Errorf(ER_NO_ENC)
-		}
+		data_dec, e.EncodingType, err = DecodeFile(data)
+	} else {
+		data_dec, err = e.EncodingType.decode(data)
 	}
-
-	//decode data from given encoding type
-	data_dec, err := e.EncodingType.decode(data)
 	if err != nil {
 		return err
 	}
@@ -106,7 +96,11 @@ func unmarshal(lines []string, lineNum *int, v reflect.Value, endSection string)
 
 		// fmt.Printf("field_id:%s, field_val=%s, endSection:%s\n", field_id, field_val, endSection)
 		if field_id == endSection {
-			return nil
+			return joinLineFields(v)
+		}
+		if lines_field, line_ind, ok := findLineField(v, field_id); ok {
+			setLineValue(lines_field, line_ind, field_val)
+			continue
 		}
 		struct_field, found, field_type, sec_end := findFieldByName(v, field_id)
 		if !found {
@@ -114,26 +108,118 @@ func unmarshal(lines []string, lineNum *int, v reflect.Value, endSection string)
 			continue
 		}
 
-		fmt.Println("ID:", field_id, "VAL:", field_val)
 		if err := setFieldValue(struct_field, field_val, field_type == FIELD_TYPE_ELEM_START, sec_end, lines, lineNum); err != nil {
 			return err
 		}
 	}
+	return joinLineFields(v)
+}
+
+// findLineField finds a numbered line of a multiline field (tagged with
+// lines:"N" and linesField:"FieldName"), for example НазначениеПлатежа3.
+// The function returns the field holding the lines and a zero based line index.
+func findLineField(v reflect.Value, tagName string) (reflect.Value, int, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		lines_field := field.Tag.Get("linesField")
+		tag := field.Tag.Get("bank")
+		if lines_field == "" || tag == "" || !strings.HasPrefix(tagName, tag) {
+			continue
+		}
+		n, err := strconv.Atoi(field.Tag.Get("lines"))
+		if err != nil {
+			continue
+		}
+		line_num, err := strconv.Atoi(tagName[len(tag):])
+		if err != nil || line_num < 1 || line_num > n {
+			continue
+		}
+		return v.FieldByName(lines_field), line_num - 1, true
+	}
+	return reflect.Value{}, 0, false
+}
+
+// setLineValue sets the line of a string slice field, extending the slice if needed.
+func setLineValue(field reflect.Value, ind int, value string) {
+	if !field.IsValid() || field.Kind() != reflect.Slice {
+		return
+	}
+	for field.Len() <= ind {
+		field.Set(reflect.Append(field, reflect.ValueOf("")))
+	}
+	field.Index(ind).SetString(value)
+}
+
+// joinLineFields trims empty trailing lines of multiline fields
+// and sets field values from their lines if values are empty.
+func joinLineFields(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		lines_field := field.Tag.Get("linesField")
+		if lines_field == "" {
+			continue
+		}
+		lines_val := v.FieldByName(lines_field)
+		if !lines_val.IsValid() || lines_val.Kind() != reflect.Slice {
+			return fmt.Errorf("Unmarshal: lines field %s not found", lines_field)
+		}
+		lines, ok := lines_val.Interface().([]string)
+		if !ok {
+			return fmt.Errorf("Unmarshal: lines field %s must be of type []string", lines_field)
+		}
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) == 0 {
+			lines = nil
+		}
+		lines_val.Set(reflect.ValueOf(lines))
+
+		if v.Field(i).Kind() == reflect.String && v.Field(i).String() == "" && len(lines) > 0 {
+			non_empty := make([]string, 0, len(lines))
+			for _, l := range lines {
+				if l = strings.TrimSpace(l); l != "" {
+					non_empty = append(non_empty, l)
+				}
+			}
+			v.Field(i).SetString(strings.Join(non_empty, " "))
+		}
+	}
 	return nil
 }
 
 // findFieldByName finds the field in the struct with the specified custom tag name.
+// Struct fields with bankPrefix tag are searched for the tag name without the prefix.
 // The function returns field value if it is found, bool indicationg if field is found,
 // the found field type and section end tag.
 func findFieldByName(v reflect.Value, tagName string) (reflect.Value, bool, ImportFieldType, string) {
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Type().Field(i)
 
+		if field_prefix, ok := field.Tag.Lookup("bankPrefix"); ok {
+			if !strings.HasPrefix(tagName, field_prefix) {
+				continue
+			}
+			struct_field, found, field_type, sec_end := findFieldByName(v.Field(i), tagName[len(field_prefix):])
+			if found {
+				return struct_field, found, field_type, sec_end
+			}
+			continue
+		}
+		if field.Tag.Get("bankFirmName") == "1" && tagName == "" {
+			return v.Field(i), true, FIELD_TYPE_FIELD, ""
+		}
+
 		tag := field.Tag.Get("bank")
 		elem_start := field.Tag.Get("bankElemStart")
 		elem_end := field.Tag.Get("bankElemEnd")
 
 		switch tagName {
+		case "":
+			continue
 		case tag:
 			return v.Field(i), true, FIELD_TYPE_FIELD, ""
 		case elem_start:
@@ -161,11 +247,9 @@ func setFieldValue(field reflect.Value, value string, isElemStart bool, endSecti
 			if ok := slice_elem.Type().Implements(reflect.TypeOf((*BankImportDocument)(nil)).Elem()); ok {
 				//determine document type by value
 				var doc_type reflect.Type
-				for i, d_tp := range DocumentTypeValues() {
-					if d_tp == value {
-						doc_type = importDocumentMaps[DocumentType(i)]
-						break
-					}
+				var d_tp DocumentType
+				if err := d_tp.Unmarshal(value); err == nil {
+					doc_type = importDocumentMaps[d_tp]
 				}
 				// if reflect.Zero(reflect.TypeOf(doc_type)) == doc_type {
 				if doc_type == nil {
@@ -195,6 +279,10 @@ func setFieldValue(field reflect.Value, value string, isElemStart bool, endSecti
 		return fmt.Errorf("tag 'bankElemStart' must belong to a struct or a slice")
 	}
 
+	//custom unmarshalers get empty values too
+	if inf, ok := field.Addr().Interface().(Unmarshaler); ok {
+		return inf.Unmarshal(value)
+	}
 	if value == "" {
 		return nil
 	}
@@ -206,9 +294,6 @@ func setFieldValue(field reflect.Value, value string, isElemStart bool, endSecti
 		field.Set(reflect.ValueOf(t))
 		return nil
 	}
-	if inf, ok := field.Addr().Interface().(Unmarshaler); ok {
-		return inf.Unmarshal(value)
-	}
 
 	switch field.Kind() {
 	case reflect.String:
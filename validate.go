@@ -0,0 +1,159 @@
+package clbnk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// Validator is implemented by structures checking their values.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	digitsExp = regexp.MustCompile(`^\d+$`)
+	kppExp    = regexp.MustCompile(`^\d{4}[\dA-Z]{2}\d{3}$`)
+)
+
+// balance tolerance, half a kopeck
+const BALANCE_EPS = 0.005
+
+func isDigits(s string, lengths ...int) bool {
+	if !digitsExp.MatchString(s) {
+		return false
+	}
+	for _, l := range lengths {
+		if len(s) == l {
+			return true
+		}
+	}
+	return len(lengths) == 0
+}
+
+// Validate checks party values, field names in errors are prefixed with the prefix.
+// Empty INN and 0 are allowed (individuals without INN).
+func (p *Party) Validate(prefix string) error {
+	errs := make([]error, 0)
+	if p.Name == "" && p.Firm == "" {
+		errs = append(errs, fmt.Errorf("%s: name is empty", prefix))
+	}
+	if p.Inn != "" && p.Inn != "0" && !isDigits(p.Inn, 10, 12) {
+		errs = append(errs, fmt.Errorf("%sИНН: invalid value %q", prefix, p.Inn))
+	}
+	if p.Kpp != "" && p.Kpp != "0" && !kppExp.MatchString(p.Kpp) {
+		errs = append(errs, fmt.Errorf("%sКПП: invalid value %q", prefix, p.Kpp))
+	}
+	if !isDigits(p.Account, 20) {
+		errs = append(errs, fmt.Errorf("%sСчет: invalid value %q", prefix, p.Account))
+	}
+	if !isDigits(p.Bank.Bik, 9) {
+		errs = append(errs, fmt.Errorf("%sБИК: invalid value %q", prefix, p.Bank.Bik))
+	}
+	if p.Bank.Account != "" && !isDigits(p.Bank.Account, 20) {
+		errs = append(errs, fmt.Errorf("%sКорсчет: invalid value %q", prefix, p.Bank.Account))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks document values required for sending to bank.
+// All found errors are returned.
+func (d *PPDocument) Validate() error {
+	errs := make([]error, 0)
+	if d.Num <= 0 {
+		errs = append(errs, fmt.Errorf("Номер: invalid value %d", d.Num))
+	}
+	if d.Date.IsZero() {
+		errs = append(errs, fmt.Errorf("Дата: empty value"))
+	}
+	if d.Sum <= 0 {
+		errs = append(errs, fmt.Errorf("Сумма: invalid value %.2f", d.Sum))
+	}
+	if err := d.Payer.Validate(PAYER_PREFIX); err != nil {
+		errs = append(errs, err)
+	}
+	if err := d.Receiver.Validate(RECEIVER_PREFIX); err != nil {
+		errs = append(errs, err)
+	}
+	if d.Order < 1 || d.Order > 5 {
+		errs = append(errs, fmt.Errorf("Очередность: invalid value %d", d.Order))
+	}
+	if d.PayComment == "" {
+		errs = append(errs, fmt.Errorf("НазначениеПлатежа: empty value"))
+	}
+	if err := checkDocumentCurrency(d); err != nil {
+		errs = append(errs, err)
+	}
+	if d.IsBudget() {
+		if d.KBKValue != "0" && len([]rune(d.KBKValue)) != 20 {
+			errs = append(errs, fmt.Errorf("ПоказательКБК: invalid value %q", d.KBKValue))
+		}
+		if d.OKATOValue != "0" && !isDigits(d.OKATOValue, 8, 11) {
+			errs = append(errs, fmt.Errorf("ОКАТО: invalid value %q", d.OKATOValue))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("document %d: %w", d.Num, err)
+	}
+	return nil
+}
+
+// Validate checks that the end balance equals the start balance
+// plus incoming minus outgoing totals.
+func (a *Account) Validate() error {
+	if !isDigits(a.Account, 20) {
+		return fmt.Errorf("РасчСчет: invalid value %q", a.Account)
+	}
+	if end := a.BalanceStart + a.Debet - a.Kredit; math.Abs(end-a.BalanceEnd) > BALANCE_EPS {
+		return fmt.Errorf("account %s: end balance %.2f, expected %.2f", a.Account, a.BalanceEnd, end)
+	}
+	return nil
+}
+
+// Validate checks account sections and all documents implementing Validator.
+func (e *BankImport) Validate() error {
+	errs := make([]error, 0)
+	for i := range e.AccSection {
+		if err := e.AccSection[i].Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, doc := range e.Documents {
+		if v, ok := doc.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks all documents implementing Validator,
+// banks of documents in the bank directory, that the documents
+// are in one currency, have unique numbers and, if CheckVat is set,
+// VAT statements. Documents are not changed, call Prepare before
+// to check values taken from the payer profile and the bank directory.
+func (e *BankExport) Validate() error {
+	errs := make([]error, 0)
+	if err := e.checkBanks(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, doc := range e.Documents {
+		if v, ok := doc.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := checkExportCurrency(e.Documents); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkDuplicateNumbers(e.Documents); err != nil {
+		errs = append(errs, err)
+	}
+	if err := e.checkVat(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,246 @@
+package clbnk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VAT phrases of payment purposes.
+const (
+	VAT_EXEMPT_PHRASE  = "НДС не облагается"
+	VAT_INCLUDE_PHRASE = "В том числе НДС"
+
+	VAT_RATE_EXEMPT = -1 // rate value for VatPhrase and SetVat meaning exemption
+)
+
+// vatRateExp finds VAT rate after the phrase: (20%), 20%, 20/120.
+var vatRateExp = regexp.MustCompile(`^\s*\(?\s*(\d+(?:[.,]\d+)?)\s*(?:%|/\s*\d+)\s*\)?`)
+
+// vatSumExp finds VAT amount after the rate: - 62-54, : 62.54, , 62.54.
+var vatSumExp = regexp.MustCompile(`^\s*[-—:=,]?\s*(?:сумма\s*)?` + invoiceAmountExp)
+
+// vatAnyExp finds VAT mention as a word in its first group,
+// \b does not work with Cyrillic letters.
+var vatAnyExp = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(ндс)(?:[^\p{L}]|$)`)
+
+// vatIncludeExp finds short "в т.ч." wording before VAT mention in its first group.
+var vatIncludeExp = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(в\s*т\.?\s*ч\.?)\s*$`)
+
+// Vat is a VAT statement of a payment purpose.
+type Vat struct {
+	Exempt  bool    // НДС не облагается, без НДС
+	Rate    float64 // percent
+	HasRate bool
+	Sum     float64
+	HasSum  bool
+}
+
+// vatStatement is a VAT statement with its position in the purpose,
+// the position covers the phrase, the rate and the amount.
+type vatStatement struct {
+	Vat
+	start int
+	end   int
+}
+
+// findVat finds the first VAT statement of the purpose after the position.
+func findVat(purpose string, from int) (vatStatement, bool) {
+	for from < len(purpose) {
+		var st vatStatement
+		var include bool
+		text := purpose[from:]
+		p := vatPhraseExp.FindStringSubmatchIndex(text)
+		a := vatAnyExp.FindStringSubmatchIndex(text)
+		if p == nil && a == nil {
+			break
+		}
+		if p != nil && (a == nil || p[2] <= a[2]) {
+			st.start, st.end = from+p[2], from+p[3]
+			//all phrases but "в том числе ндс" are exemptions
+			if !strings.HasPrefix(strings.ToLower(text[p[2]:p[3]]), "в") {
+				st.Exempt = true
+				return st, true
+			}
+			include = true
+		} else {
+			st.start, st.end = from+a[2], from+a[3]
+			if m := vatIncludeExp.FindStringSubmatchIndex(purpose[:st.start]); m != nil && m[2] >= from {
+				st.start, include = m[2], true
+			}
+		}
+
+		if m := vatRateExp.FindStringSubmatchIndex(purpose[st.end:]); m != nil {
+			if r, err := strconv.ParseFloat(strings.Replace(purpose[st.end+m[2]:st.end+m[3]], ",", ".", 1), 64); err == nil {
+				st.Rate, st.HasRate = r, true
+			}
+			st.end += m[1]
+		}
+		if st.HasRate || include {
+			if m := vatSumExp.FindStringSubmatchIndex(purpose[st.end:]); m != nil {
+				if s, err := parseAmount(purpose[st.end+m[2] : st.end+m[3]]); err == nil {
+					st.Sum, st.HasSum = s, true
+					st.end += m[1]
+				}
+			}
+		}
+		if st.HasRate || st.HasSum {
+			return st, true
+		}
+		from = st.end
+	}
+	return vatStatement{}, false
+}
+
+// findVats finds all VAT statements of the purpose.
+func findVats(purpose string) []vatStatement {
+	res := make([]vatStatement, 0)
+	for from := 0; ; {
+		st, ok := findVat(purpose, from)
+		if !ok {
+			return res
+		}
+		res = append(res, st)
+		from = st.end
+	}
+}
+
+// ParseVat finds the first VAT statement in the purpose.
+// An amount is taken as VAT only after a rate or "в том числе" wording.
+// False is returned if there is no VAT statement.
+func ParseVat(purpose string) (Vat, bool) {
+	st, ok := findVat(purpose, 0)
+	return st.Vat, ok
+}
+
+// VatSum returns VAT included into the sum at the rate, rounded to kopecks.
+func VatSum(sum, rate float64) float64 {
+	return math.Round(sum*rate/(100+rate)*100) / 100
+}
+
+// formatVatSum formats the amount as rubles-kopecks: 62-54.
+func formatVatSum(sum float64) string {
+	kop := int64(math.Round(sum * 100))
+	return fmt.Sprintf("%d-%02d", kop/100, kop%100)
+}
+
+// VatPhrase returns the VAT phrase for the sum at the rate,
+// for example "В том числе НДС (20%) 62-54".
+// VAT_RATE_EXEMPT rate gives "НДС не облагается".
+func VatPhrase(sum, rate float64) string {
+	if rate < 0 {
+		return VAT_EXEMPT_PHRASE
+	}
+	return fmt.Sprintf("%s (%s%%) %s", VAT_INCLUDE_PHRASE, strconv.FormatFloat(rate, 'f', -1, 64), formatVatSum(VatSum(sum, rate)))
+}
+
+// CheckVat checks that the purpose states VAT and the stated VAT amount
+// equals the VAT of the sum at the stated rate. Difference of one kopeck is allowed.
+// The purpose must not state VAT rates or amounts more than once.
+func CheckVat(sum float64, purpose string) error {
+	vats := findVats(purpose)
+	if len(vats) == 0 {
+		return fmt.Errorf("VAT is not stated")
+	}
+	vat := vats[0].Vat
+	for _, st := range vats[1:] {
+		if !vat.Exempt || !st.Exempt {
+			return fmt.Errorf("VAT is stated %d times", len(vats))
+		}
+	}
+	if vat.Exempt || !vat.HasRate || !vat.HasSum {
+		return nil
+	}
+	if expected := VatSum(sum, vat.Rate); math.Abs(expected-vat.Sum) > 0.011 {
+		return fmt.Errorf("VAT %.2f differs from %.2f at %s%% of %.2f", vat.Sum, expected, strconv.FormatFloat(vat.Rate, 'f', -1, 64), sum)
+	}
+	return nil
+}
+
+// cutText removes the text between start and end with one of separators around it,
+// the rest of the line is kept: "товары, НДС не облагается, по договору" gives "товары, по договору".
+func cutText(s string, start, end int) string {
+	const SEPARATORS = ",;"
+	before := strings.TrimRight(s[:start], " \t")
+	after := strings.TrimLeft(s[end:], " \t")
+	line_start := before == "" || strings.HasSuffix(before, "\n")
+	line_end := after == "" || strings.HasPrefix(after, "\n") || strings.HasPrefix(after, "\r")
+	if after != "" && strings.ContainsRune(SEPARATORS, rune(after[0])) &&
+		(line_start || strings.ContainsRune(SEPARATORS, rune(before[len(before)-1]))) {
+		after = strings.TrimLeft(after[1:], " \t")
+		line_end = after == "" || strings.HasPrefix(after, "\n") || strings.HasPrefix(after, "\r")
+	}
+	if line_end {
+		before = strings.TrimRight(before, SEPARATORS+" \t")
+	}
+	if line_start || line_end {
+		return before + after
+	}
+	return before + " " + after
+}
+
+// setVatPhrase removes the VAT statements of the purpose
+// and adds the new phrase on a new line.
+func setVatPhrase(purpose, phrase string) string {
+	vats := findVats(purpose)
+	for i := len(vats) - 1; i >= 0; i-- {
+		purpose = cutText(purpose, vats[i].start, vats[i].end)
+	}
+	purpose = strings.TrimSpace(purpose)
+	if purpose == "" {
+		return phrase
+	}
+	return purpose + "\n" + phrase
+}
+
+// Vat returns the VAT statement of the purpose.
+func (d *PPDocument) Vat() (Vat, bool) {
+	return ParseVat(d.PayComment)
+}
+
+// SetVat adds the VAT phrase of the document sum at the rate to the purpose,
+// the existing VAT phrase is replaced. VAT_RATE_EXEMPT rate means exemption.
+func (d *PPDocument) SetVat(rate float64) {
+	d.PayComment = setVatPhrase(d.PayComment, VatPhrase(d.Sum, rate))
+}
+
+// CheckVat checks the VAT statement of the purpose.
+func (d *PPDocument) CheckVat() error {
+	if err := CheckVat(d.Sum, d.PayComment); err != nil {
+		return fmt.Errorf("document %d: НазначениеПлатежа: %v", d.Num, err)
+	}
+	return nil
+}
+
+// Vat returns the VAT statement of the purpose.
+func (d *BankOrderDocument) Vat() (Vat, bool) {
+	return ParseVat(d.PayComment)
+}
+
+// VatChecker is implemented by documents checking their VAT statements.
+type VatChecker interface {
+	CheckVat() error
+}
+
+// checkVat checks VAT statements of all documents if CheckVat is set.
+// Budget payments are not checked.
+func (e *BankExport) checkVat() error {
+	if !e.CheckVat {
+		return nil
+	}
+	errs := make([]error, 0)
+	for _, doc := range e.Documents {
+		if d, ok := doc.(*PPDocument); ok && d.IsBudget() {
+			continue
+		}
+		if d, ok := doc.(VatChecker); ok {
+			if err := d.CheckVat(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
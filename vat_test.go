@@ -0,0 +1,121 @@
+package clbnk
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVat(t *testing.T) {
+	tests := []struct {
+		purpose string
+		vat     Vat
+		found   bool
+	}{
+		{"За товары, по счету №777 на сумму 375-25\nВ том числе НДС (20%) 62-54", Vat{Rate: 20, HasRate: true, Sum: 62.54, HasSum: true}, true},
+		{"Оплата по заказу клиента №9614, НДС не облагается", Vat{Exempt: true}, true},
+		{"Оплата услуг. Без НДС", Vat{Exempt: true}, true},
+		{"Оплата, в т.ч. НДС 10% - 1 000,00", Vat{Rate: 10, HasRate: true, Sum: 1000, HasSum: true}, true},
+		{"Оплата, в том числе НДС 20/120 - 100.00", Vat{Rate: 20, HasRate: true, Sum: 100, HasSum: true}, true},
+		{"Оплата, в том числе НДС 29166-67", Vat{Sum: 29166.67, HasSum: true}, true},
+		{"За товары, по счету №125 на сумму 175000-00", Vat{}, false},
+		{"Оплата, в т. ч. НДС 150-00", Vat{Sum: 150, HasSum: true}, true},
+		{"НДС(10%)", Vat{Rate: 10, HasRate: true}, true},
+		{"Оплата, в т.ч. НДС 20%, 999.00", Vat{Rate: 20, HasRate: true, Sum: 999, HasSum: true}, true},
+		//VAT as a part of a word
+		{"Оплата ООО Фондс 1500 по договору", Vat{}, false},
+		//amount without rate or "в том числе"
+		{"Оплата, НДС 1500 по договору", Vat{}, false},
+	}
+	for _, tt := range tests {
+		vat, found := ParseVat(tt.purpose)
+		if found != tt.found || vat != tt.vat {
+			t.Fatalf("%q: expected %+v %v, got %+v %v", tt.purpose, tt.vat, tt.found, vat, found)
+		}
+	}
+}
+
+func TestVatPhrase(t *testing.T) {
+	if s := VatPhrase(375.25, 20); s != "В том числе НДС (20%) 62-54" {
+		t.Fatalf("VatPhrase, got %s", s)
+	}
+	if s := VatPhrase(175000, 20); s != "В том числе НДС (20%) 29166-67" {
+		t.Fatalf("VatPhrase, got %s", s)
+	}
+	if s := VatPhrase(100, VAT_RATE_EXEMPT); s != VAT_EXEMPT_PHRASE {
+		t.Fatalf("VatPhrase of exemption, got %s", s)
+	}
+
+	d := &PPDocument{Sum: 1100, PayComment: "За товары по счету №1"}
+	d.SetVat(10)
+	if d.PayComment != "За товары по счету №1\nВ том числе НДС (10%) 100-00" {
+		t.Fatalf("SetVat, got %q", d.PayComment)
+	}
+	d.SetVat(VAT_RATE_EXEMPT)
+	if d.PayComment != "За товары по счету №1\nНДС не облагается" {
+		t.Fatalf("SetVat replacing phrase, got %q", d.PayComment)
+	}
+	if vat, ok := d.Vat(); !ok || !vat.Exempt {
+		t.Fatalf("Vat, got %+v", vat)
+	}
+
+	//phrase in the middle of a line
+	d = &PPDocument{Sum: 1200, PayComment: "За товары, НДС не облагается, по договору №5 от 01.02.2024"}
+	d.SetVat(20)
+	if d.PayComment != "За товары, по договору №5 от 01.02.2024\nВ том числе НДС (20%) 200-00" {
+		t.Fatalf("SetVat replacing phrase in the middle of a line, got %q", d.PayComment)
+	}
+	d = &PPDocument{Sum: 1200, PayComment: "За товары в т.ч. НДС 20% 200-00 по договору №5\nОплата услуг. Без НДС"}
+	d.SetVat(20)
+	if d.PayComment != "За товары по договору №5\nОплата услуг.\nВ том числе НДС (20%) 200-00" {
+		t.Fatalf("SetVat replacing phrases in the middle and at the end of lines, got %q", d.PayComment)
+	}
+
+	//short wording is replaced too
+	d = &PPDocument{Sum: 1200, PayComment: "За товары\nв т.ч. НДС 20% 200-00"}
+	d.SetVat(10)
+	if d.PayComment != "За товары\nВ том числе НДС (10%) 109-09" {
+		t.Fatalf("SetVat replacing short phrase, got %q", d.PayComment)
+	}
+}
+
+func TestCheckVat(t *testing.T) {
+	if err := CheckVat(375.25, "В том числе НДС (20%) 62-54"); err != nil {
+		t.Fatalf("CheckVat failed: %v", err)
+	}
+	if err := CheckVat(375.25, "В том числе НДС (20%) 62-55"); err != nil {
+		t.Fatalf("CheckVat must allow one kopeck difference: %v", err)
+	}
+	if err := CheckVat(375.25, "В том числе НДС (20%) 75-05"); err == nil {
+		t.Fatal("CheckVat of wrong VAT sum must fail")
+	}
+	if err := CheckVat(375.25, "За товары"); err == nil {
+		t.Fatal("CheckVat without VAT statement must fail")
+	}
+	if err := CheckVat(1200, "в т.ч. НДС 20%, 999.00"); err == nil {
+		t.Fatal("CheckVat of wrong VAT sum after comma must fail")
+	}
+	if err := CheckVat(1200, "Оплата, в т.ч. НДС 20% 200-00\nВ том числе НДС (10%) 109-09"); err == nil {
+		t.Fatal("CheckVat of two VAT statements must fail")
+	}
+	if err := CheckVat(1500, "Оплата ООО Фондс 1500 по договору"); err == nil {
+		t.Fatal("CheckVat of a word containing НДС must fail")
+	}
+
+	doc := &PPDocument{Num: 1, Date: time.Now(), Sum: 1200, PayComment: "За товары",
+		Payer:    Party{Name: "ООО Тест", Account: "40702810000000000001"},
+		Receiver: Party{Name: "ИП Иванов А.А.", Account: "40802810000000000002"},
+	}
+	exp := NewBankExport([]BankExportDocument{doc})
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal without VAT check failed: %v", err)
+	}
+	exp.CheckVat = true
+	if _, err := exp.Marshal(); err == nil || !strings.Contains(err.Error(), "VAT is not stated") {
+		t.Fatalf("Marshal without VAT statement must fail, got %v", err)
+	}
+	doc.SetVat(20)
+	if _, err := exp.Marshal(); err != nil {
+		t.Fatalf("Marshal with VAT statement failed: %v", err)
+	}
+}